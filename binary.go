@@ -0,0 +1,138 @@
+package meta
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// maxBinaryDepth limits how deeply MessagePack and CBOR input can nest, since they're parsed recursively.
+const maxBinaryDepth = 10000
+
+type binaryError string
+
+func (e binaryError) Error() string {
+	return "meta: " + string(e)
+}
+
+var errBinaryEOF = binaryError("unexpected end of input")
+
+// newBinarySource is a source for a MessagePack or CBOR value. The values are the same as a JSON source's, except that
+// integers are int64, or uint64 if they're too big for that, floats are float64, byte strings are []byte and timestamps
+// are time.Time.
+func newBinarySource(b []byte, parse func(r *binaryReader) (interface{}, error)) Source {
+	if len(b) == 0 {
+		return &jsonSource{}
+	}
+	r := &binaryReader{b: b}
+	value, err := parse(r)
+	if err == nil && r.pos != len(b) {
+		err = binaryError("data after the top-level value")
+	}
+	if err != nil {
+		return &jsonSource{present: true, invalid: true}
+	}
+	return &jsonSource{value: value, present: true}
+}
+
+// binaryReader reads big-endian binary input.
+type binaryReader struct {
+	b     []byte
+	pos   int
+	depth int
+}
+
+func (r *binaryReader) byte() (byte, error) {
+	if r.pos >= len(r.b) {
+		return 0, errBinaryEOF
+	}
+	r.pos += 1
+	return r.b[r.pos-1], nil
+}
+
+func (r *binaryReader) next(n uint64) ([]byte, error) {
+	if n > uint64(len(r.b)-r.pos) {
+		return nil, errBinaryEOF
+	}
+	b := r.b[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+// uint reads an n byte unsigned integer.
+func (r *binaryReader) uint(n int) (uint64, error) {
+	b, err := r.next(uint64(n))
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+// bytes reads an n byte string, copied so it doesn't alias the input.
+func (r *binaryReader) bytes(n uint64) ([]byte, error) {
+	b, err := r.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{}, b...), nil
+}
+
+// capacity is how many elements to allocate for a container that claims to have n:
+// each element is at least a byte, so a bogus n can't be bigger than the rest of the input.
+func (r *binaryReader) capacity(n uint64) int {
+	if remaining := uint64(len(r.b) - r.pos); n > remaining {
+		return int(remaining)
+	}
+	return int(n)
+}
+
+func (r *binaryReader) nest() error {
+	r.depth += 1
+	if r.depth > maxBinaryDepth {
+		return binaryError("input is nested too deeply")
+	}
+	return nil
+}
+
+func (r *binaryReader) unnest() {
+	r.depth -= 1
+}
+
+// binaryInt is an unsigned integer as an int64, or a uint64 if it's too big.
+func binaryInt(u uint64) interface{} {
+	if u <= math.MaxInt64 {
+		return int64(u)
+	}
+	return u
+}
+
+// bigInt is n as an int64 or uint64, or a json.Number if it's too big for either.
+func bigInt(n *big.Int) interface{} {
+	if n.IsInt64() {
+		return n.Int64()
+	}
+	if n.IsUint64() {
+		return n.Uint64()
+	}
+	return json.Number(n.String())
+}
+
+// binaryKey is a map key as a meta name. Integer keys are allowed, so a map can be a sparse slice.
+func binaryKey(key interface{}) (string, error) {
+	switch k := key.(type) {
+	case string:
+		return k, nil
+	case []byte:
+		return string(k), nil
+	case int64:
+		return strconv.FormatInt(k, 10), nil
+	case uint64:
+		return strconv.FormatUint(k, 10), nil
+	}
+	return "", binaryError("map keys must be strings or integers")
+}
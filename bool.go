@@ -3,6 +3,7 @@ package meta
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"reflect"
 	"strconv"
 )
@@ -99,6 +100,8 @@ func (b *Bool) JSONValue(path string, i interface{}, options interface{}) Errora
 		return b.FormValue(value, options)
 	case json.Number:
 		return b.FormValue(string(value), options)
+	case int64:
+		return b.FormValue(strconv.FormatInt(value, 10), options)
 	case bool:
 		b.Val = value
 		b.Present = true
@@ -115,9 +118,79 @@ func (b Bool) Value() (driver.Value, error) {
 	return nil, nil
 }
 
+// Scan implements sql.Scanner. A NULL column sets Null.
+func (b *Bool) Scan(src interface{}) error {
+	b.Val, b.Null, b.Present = false, false, true
+	switch value := src.(type) {
+	case nil:
+		b.Null = true
+		return nil
+	case bool:
+		b.Val = value
+		return nil
+	case int64:
+		if value == 0 || value == 1 {
+			b.Val = value == 1
+			return nil
+		}
+	case string:
+		if v, err := strconv.ParseBool(value); err == nil {
+			b.Val = v
+			return nil
+		}
+	case []byte:
+		if v, err := strconv.ParseBool(string(value)); err == nil {
+			b.Val = v
+			return nil
+		}
+	}
+	b.Present = false
+	return fmt.Errorf("meta: cannot scan %T(%v) into Bool", src, src)
+}
+
 func (b Bool) MarshalJSON() ([]byte, error) {
 	if b.Present && !b.Null {
 		return MetaJson.Marshal(b.Val)
 	}
 	return nullString, nil
 }
+
+// UnmarshalJSON implements json.Unmarshaler using the default options. null sets Null, so values round trip through MarshalJSON.
+func (b *Bool) UnmarshalJSON(data []byte) error {
+	*b = Bool{}
+	if isJSONNull(data) {
+		b.Present = true
+		b.Null = true
+		return nil
+	}
+	opts := b.ParseOptions("")
+	return unmarshalJSONValue(b, data, opts)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using the default options.
+func (b *Bool) UnmarshalText(text []byte) error {
+	*b = Bool{}
+	opts := b.ParseOptions("")
+	if err := b.FormValue(string(text), opts); err != nil {
+		return err
+	}
+	return nil
+}
+
+// String implements flag.Value.
+func (b *Bool) String() string {
+	if !b.Present || b.Null {
+		return ""
+	}
+	return strconv.FormatBool(b.Val)
+}
+
+// Set implements flag.Value using the default options.
+func (b *Bool) Set(value string) error {
+	return b.UnmarshalText([]byte(value))
+}
+
+// IsBoolFlag lets a Bool flag be given without a value, eg -debug for -debug=true.
+func (b *Bool) IsBoolFlag() bool {
+	return true
+}
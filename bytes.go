@@ -0,0 +1,223 @@
+package meta
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//
+// Bytes
+//
+
+// Bytes is binary data. Binary formats like MessagePack and CBOR give it their byte strings as they are;
+// anywhere else, eg JSON or a form, it's a base64 string, standard or URL-safe, with or without padding.
+type Bytes struct {
+	Val []byte
+	Nullity
+	Presence
+	Path string
+}
+
+type BytesOptions struct {
+	Required         bool
+	DiscardBlank     bool
+	Null             bool
+	MinLengthPresent bool
+	MinLength        int
+	MaxLengthPresent bool
+	MaxLength        int
+}
+
+func NewBytes(b []byte) Bytes {
+	return Bytes{b, Nullity{false}, Presence{true}, ""}
+}
+
+func (b *Bytes) ParseOptions(tag reflect.StructTag) interface{} {
+	opts := &BytesOptions{
+		Required:     false,
+		DiscardBlank: true,
+		Null:         false,
+	}
+
+	if tag.Get("meta_required") == "true" {
+		opts.Required = true
+	}
+
+	if tag.Get("meta_discard_blank") == "false" {
+		opts.DiscardBlank = false
+	}
+
+	if tag.Get("meta_null") == "true" {
+		opts.Null = true
+	}
+
+	if minLengthString := tag.Get("meta_min_length"); minLengthString != "" {
+		minLength, err := strconv.ParseInt(minLengthString, 10, 0)
+		if err != nil {
+			panic(err.Error())
+		}
+
+		opts.MinLengthPresent = true
+		opts.MinLength = int(minLength)
+	}
+
+	if maxLengthString := tag.Get("meta_max_length"); maxLengthString != "" {
+		maxLength, err := strconv.ParseInt(maxLengthString, 10, 0)
+		if err != nil {
+			panic(err.Error())
+		}
+
+		opts.MaxLengthPresent = true
+		opts.MaxLength = int(maxLength)
+	}
+
+	return opts
+}
+
+func (b *Bytes) FormValue(value string, options interface{}) Errorable {
+	if value == "" {
+		return b.validateValue(nil, options)
+	}
+
+	v, err := decodeBase64(value)
+	if err != nil {
+		return ErrBytes
+	}
+	return b.validateValue(v, options)
+}
+
+func (b *Bytes) JSONValue(path string, i interface{}, options interface{}) Errorable {
+	b.Path = path
+	if i == nil {
+		opts := options.(*BytesOptions)
+		if opts.Null {
+			b.Present = true
+			b.Null = true
+			return nil
+		}
+		return b.FormValue("", options)
+	}
+
+	switch value := i.(type) {
+	case []byte:
+		return b.validateValue(value, options)
+	case string:
+		return b.FormValue(value, options)
+	}
+	return ErrBytes
+}
+
+func (b *Bytes) validateValue(value []byte, options interface{}) Errorable {
+	opts := options.(*BytesOptions)
+
+	if len(value) == 0 {
+		if opts.Null {
+			b.Present = true
+			b.Null = true
+			return nil
+		}
+		if opts.Required {
+			return ErrBlank
+		}
+		if !opts.DiscardBlank {
+			b.Present = true
+			return ErrBlank
+		}
+		return nil
+	}
+
+	if opts.MinLengthPresent && len(value) < opts.MinLength {
+		return ErrMinLength
+	}
+
+	if opts.MaxLengthPresent && len(value) > opts.MaxLength {
+		return ErrMaxLength
+	}
+
+	b.Val = value
+	b.Present = true
+	return nil
+}
+
+// decodeBase64 accepts standard or URL-safe base64, padded or not.
+func decodeBase64(s string) ([]byte, error) {
+	encoding := base64.StdEncoding
+	if strings.ContainsAny(s, "-_") {
+		encoding = base64.URLEncoding
+	}
+	if !strings.HasSuffix(s, "=") {
+		encoding = encoding.WithPadding(base64.NoPadding)
+	}
+	return encoding.DecodeString(s)
+}
+
+func (b Bytes) Value() (driver.Value, error) {
+	if b.Present && !b.Null {
+		return b.Val, nil
+	}
+	return nil, nil
+}
+
+// Scan implements sql.Scanner. A NULL column sets Null.
+func (b *Bytes) Scan(src interface{}) error {
+	b.Val, b.Null, b.Present = nil, false, true
+	switch value := src.(type) {
+	case nil:
+		b.Null = true
+	case []byte:
+		b.Val = append([]byte(nil), value...)
+	case string:
+		b.Val = []byte(value)
+	default:
+		b.Present = false
+		return fmt.Errorf("meta: cannot scan %T into Bytes", src)
+	}
+	return nil
+}
+
+// MarshalJSON writes standard, padded base64.
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	if b.Present && !b.Null {
+		return MetaJson.Marshal(b.Val)
+	}
+	return nullString, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler using the default options. null sets Null, so values round trip through MarshalJSON.
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	*b = Bytes{}
+	if isJSONNull(data) {
+		b.Present = true
+		b.Null = true
+		return nil
+	}
+	opts := b.ParseOptions("")
+	return unmarshalJSONValue(b, data, opts)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using the default options.
+func (b *Bytes) UnmarshalText(text []byte) error {
+	*b = Bytes{}
+	opts := b.ParseOptions("")
+	if err := b.FormValue(string(text), opts); err != nil {
+		return err
+	}
+	return nil
+}
+
+// String implements flag.Value.
+func (b *Bytes) String() string {
+	if !b.Present || b.Null {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(b.Val)
+}
+
+// Set implements flag.Value using the default options.
+func (b *Bytes) Set(value string) error {
+	return b.UnmarshalText([]byte(value))
+}
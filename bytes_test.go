@@ -0,0 +1,57 @@
+package meta
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+)
+
+type withBytes struct {
+	A Bytes `meta_required:"true" meta_max_length:"4"`
+	B Bytes `meta_null:"true"`
+}
+
+var withBytesDecoder = NewDecoder(&withBytes{})
+
+func TestBytesSuccess(t *testing.T) {
+	for _, s := range []string{"AQL/", "AQL_", "AQI=", "AQI"} {
+		var inputs withBytes
+		e := withBytesDecoder.DecodeValues(&inputs, url.Values{"a": {s}})
+		assertEqual(t, e, ErrorHash(nil))
+		assertEqual(t, inputs.A.Present, true)
+	}
+
+	var inputs withBytes
+	e := withBytesDecoder.DecodeJSON(&inputs, []byte(`{"a":"AQID","b":null}`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, []byte{1, 2, 3})
+	assertEqual(t, inputs.B.Null, true)
+
+	inputs = withBytes{}
+	e = withBytesDecoder.DecodeMap(&inputs, map[string]interface{}{"a": []byte{1, 2, 3}})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, []byte{1, 2, 3})
+}
+
+func TestBytesErrors(t *testing.T) {
+	var inputs withBytes
+	e := withBytesDecoder.DecodeJSON(&inputs, []byte(`{"a":"not base64!","b":1}`))
+	assertEqual(t, e, ErrorHash{"a": ErrBytes, "b": ErrBytes})
+
+	e = withBytesDecoder.DecodeMap(&inputs, map[string]interface{}{"a": []byte{1, 2, 3, 4, 5}})
+	assertEqual(t, e, ErrorHash{"a": ErrMaxLength})
+
+	e = withBytesDecoder.DecodeMap(&inputs, map[string]interface{}{"a": []byte{}})
+	assertEqual(t, e, ErrorHash{"a": ErrBlank})
+}
+
+func TestBytesJSON(t *testing.T) {
+	b, err := json.Marshal(NewBytes([]byte{1, 2, 3}))
+	assertEqual(t, err, nil)
+	assertEqual(t, string(b), `"AQID"`)
+
+	var v Bytes
+	assertEqual(t, json.Unmarshal(b, &v), nil)
+	assertEqual(t, v.Val, []byte{1, 2, 3})
+	assertEqual(t, v.String(), "AQID")
+}
@@ -0,0 +1,276 @@
+package meta
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// DecodeCBOR decodes a CBOR value, like DecodeJSON. Input that can't be parsed is ErrMalformed.
+func (d *Decoder) DecodeCBOR(dest interface{}, b []byte) ErrorHash {
+	return d.decodeBody(dest, b, NewCBORSource)
+}
+
+// NewCBORSource reads a CBOR value. Like NewMsgPackSource, numbers, byte strings and date/times (tags 0 and 1) are
+// passed to the fields as they are. Bignums (tags 2 and 3) are integers, and other tags are ignored. Map keys must be
+// strings or integers, and undefined is null.
+func NewCBORSource(b []byte) Source {
+	return newBinarySource(b, parseCBOR)
+}
+
+const cborBreak = 0xff
+
+func parseCBOR(r *binaryReader) (interface{}, error) {
+	c, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	major, info := c>>5, c&0x1f
+
+	if major == 7 {
+		return parseCBORSimple(r, info)
+	}
+	if info == 31 {
+		return parseCBORIndefinite(r, major)
+	}
+
+	n, err := cborArgument(r, info)
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case 0:
+		return binaryInt(n), nil
+	case 1:
+		if n <= math.MaxInt64 {
+			return -1 - int64(n), nil
+		}
+		neg := new(big.Int).SetUint64(n)
+		return bigInt(neg.Neg(neg.Add(neg, big.NewInt(1)))), nil
+	case 2:
+		return r.bytes(n)
+	case 3:
+		b, err := r.next(n)
+		return string(b), err
+	case 4:
+		if err := r.nest(); err != nil {
+			return nil, err
+		}
+		defer r.unnest()
+		out := make([]interface{}, 0, r.capacity(n))
+		for i := uint64(0); i < n; i++ {
+			el, err := parseCBOR(r)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, el)
+		}
+		return out, nil
+	case 5:
+		if err := r.nest(); err != nil {
+			return nil, err
+		}
+		defer r.unnest()
+		out := make(map[string]interface{}, r.capacity(n))
+		for i := uint64(0); i < n; i++ {
+			if err := parseCBORPair(r, out); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+	return parseCBORTag(r, n)
+}
+
+// cborArgument reads the argument of an item: its value, length or tag number.
+func cborArgument(r *binaryReader, info byte) (uint64, error) {
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info <= 27:
+		return r.uint(1 << (info - 24))
+	}
+	return 0, binaryError("cbor: invalid additional information")
+}
+
+func parseCBORPair(r *binaryReader, out map[string]interface{}) error {
+	k, err := parseCBOR(r)
+	if err != nil {
+		return err
+	}
+	key, err := binaryKey(k)
+	if err != nil {
+		return err
+	}
+	out[key], err = parseCBOR(r)
+	return err
+}
+
+// atBreak reports whether the next byte ends an indefinite length item, and consumes it if so.
+func atBreak(r *binaryReader) (bool, error) {
+	if r.pos >= len(r.b) {
+		return false, errBinaryEOF
+	}
+	if r.b[r.pos] == cborBreak {
+		r.pos += 1
+		return true, nil
+	}
+	return false, nil
+}
+
+func parseCBORIndefinite(r *binaryReader, major byte) (interface{}, error) {
+	if err := r.nest(); err != nil {
+		return nil, err
+	}
+	defer r.unnest()
+
+	switch major {
+	case 2, 3:
+		// Chunks of definite length strings of the same type
+		var b []byte
+		for {
+			done, err := atBreak(r)
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				break
+			}
+			c, _ := r.byte()
+			if c>>5 != major || c&0x1f == 31 {
+				return nil, binaryError("cbor: invalid chunk in an indefinite length string")
+			}
+			n, err := cborArgument(r, c&0x1f)
+			if err != nil {
+				return nil, err
+			}
+			chunk, err := r.next(n)
+			if err != nil {
+				return nil, err
+			}
+			b = append(b, chunk...)
+		}
+		if major == 3 {
+			return string(b), nil
+		}
+		if b == nil {
+			b = []byte{}
+		}
+		return b, nil
+	case 4:
+		out := []interface{}{}
+		for {
+			done, err := atBreak(r)
+			if err != nil || done {
+				return out, err
+			}
+			el, err := parseCBOR(r)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, el)
+		}
+	case 5:
+		out := map[string]interface{}{}
+		for {
+			done, err := atBreak(r)
+			if err != nil || done {
+				return out, err
+			}
+			if err := parseCBORPair(r, out); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, binaryError("cbor: invalid indefinite length item")
+}
+
+func parseCBORSimple(r *binaryReader, info byte) (interface{}, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23: // null, undefined
+		return nil, nil
+	case 25:
+		u, err := r.uint(2)
+		return halfFloat(uint16(u)), err
+	case 26:
+		u, err := r.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 27:
+		u, err := r.uint(8)
+		return math.Float64frombits(u), err
+	case 31:
+		return nil, binaryError("cbor: unexpected break")
+	}
+	return nil, binaryError("cbor: unsupported simple value " + strconv.Itoa(int(info)))
+}
+
+func parseCBORTag(r *binaryReader, tag uint64) (interface{}, error) {
+	if err := r.nest(); err != nil {
+		return nil, err
+	}
+	defer r.unnest()
+
+	content, err := parseCBOR(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case 0: // RFC 3339 date/time
+		if s, ok := content.(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				return t, nil
+			}
+		}
+		return nil, binaryError("cbor: invalid date/time")
+	case 1: // epoch date/time
+		switch v := content.(type) {
+		case int64:
+			return time.Unix(v, 0).UTC(), nil
+		case float64:
+			if !math.IsNaN(v) && !math.IsInf(v, 0) {
+				sec, frac := math.Modf(v)
+				return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+			}
+		}
+		return nil, binaryError("cbor: invalid epoch date/time")
+	case 2, 3: // bignum
+		b, ok := content.([]byte)
+		if !ok {
+			return nil, binaryError("cbor: invalid bignum")
+		}
+		n := new(big.Int).SetBytes(b)
+		if tag == 3 {
+			n.Neg(n.Add(n, big.NewInt(1)))
+		}
+		return bigInt(n), nil
+	}
+	return content, nil
+}
+
+// halfFloat converts an IEEE 754 half precision float.
+func halfFloat(h uint16) float64 {
+	exp, mant := int(h>>10)&0x1f, float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
@@ -0,0 +1,73 @@
+package meta
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+var cborBinary = "\xa9" +
+	"\x64name\x61x" +
+	"\x65count\x02" +
+	"\x63big\x1b\xff\xff\xff\xff\xff\xff\xff\xff" +
+	"\x65ratio\xf9\x3e\x00" +
+	"\x64data\x43\x01\x02\x03" +
+	"\x64when\xc1\x1a\x5e\x0d\x5d\xa5" +
+	"\x64tags\x9f\x61a\x61b\xff" +
+	"\x62ok\xf5" +
+	"\x65items\x81\xa1\x65price\x21"
+
+func TestDecodeCBOR(t *testing.T) {
+	var inputs withBinary
+	e := withBinaryDecoder.DecodeCBOR(&inputs, []byte(cborBinary))
+	assertEqual(t, e, ErrorHash(nil))
+	assertBinary(t, inputs)
+
+	e = withBinaryDecoder.DecodeCBOR(&withBinary{}, []byte("\xa2\x64name\x61x\x65count\x3b\xff\xff\xff\xff\xff\xff\xff\xff"))
+	assertEqual(t, e, ErrorHash{"count": ErrIntRange})
+
+	e = withBinaryDecoder.DecodeCBOR(&withBinary{}, []byte(cborBinary+"\x00"))
+	assertEqual(t, e, ErrorHash{"error": ErrMalformed})
+}
+
+func TestParseCBOR(t *testing.T) {
+	tests := []struct {
+		cbor string
+		want interface{}
+	}{
+		{"\x3b\x7f\xff\xff\xff\xff\xff\xff\xff", int64(math.MinInt64)},
+		{"\x3b\xff\xff\xff\xff\xff\xff\xff\xff", json.Number("-18446744073709551616")},
+		{"\xc2\x49\x01\x00\x00\x00\x00\x00\x00\x00\x00", json.Number("18446744073709551616")},
+		{"\xc3\x41\x01", int64(-2)},
+		{"\xfa\x3f\xc0\x00\x00", 1.5},
+		{"\xf9\x7c\x00", math.Inf(1)},
+		{"\xf9\x00\x01", math.Ldexp(1, -24)},
+		{"\x5f\x42\x01\x02\x41\x03\xff", []byte{1, 2, 3}},
+		{"\x7f\x61a\x61b\xff", "ab"},
+		{"\xbf\x01\x61x\xff", map[string]interface{}{"1": "x"}},
+		{"\xf7", nil},
+		{"\xd9\xd9\xf7\x01", int64(1)},
+		{"\xc0\x742020-01-02T03:04:05Z", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"\xc1\xfb\x3f\xf8\x00\x00\x00\x00\x00\x00", time.Unix(1, 5e8).UTC()},
+	}
+	for _, test := range tests {
+		got, err := parseCBOR(&binaryReader{b: []byte(test.cbor)})
+		if err != nil {
+			t.Errorf("%x: %s", test.cbor, err)
+			continue
+		}
+		assertEqual(t, got, test.want)
+	}
+
+	for _, cbor := range []string{"\xff", "\xf8\x10", "\x1c", "\x5f\x61a\xff", "\xc0\x01", "\xa1\xf6\x01", "\x9f\x01", strings.Repeat("\x81", maxBinaryDepth+1) + "\x00"} {
+		if _, err := parseCBOR(&binaryReader{b: []byte(cbor)}); err == nil {
+			t.Errorf("%x: expected an error", cbor)
+		}
+	}
+}
+
+func TestDecodeRequestCBOR(t *testing.T) {
+	assertDecodesRequest(t, "application/cbor", "\xa2\x61a\x611\x61c\xa1\x61d\x03")
+}
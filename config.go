@@ -0,0 +1,135 @@
+package meta
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+)
+
+// Config layer names. A JSON file's layer is named after its path.
+const (
+	LayerDefault = "default" // meta_default tags
+	LayerFlags   = "flags"
+)
+
+// Config loads a struct, eg a service's configuration, from layers: meta_default tags, then each layer in the order
+// it was added. Later layers override earlier ones field by field, so a typical order is files, then env, then flags:
+//
+//	config := meta.NewConfig(meta.NewDecoder(&Settings{}))
+//	if err := config.AddJSONFile("settings.json"); err != nil { ... }
+//	config.AddEnv("APP")
+//	if err := config.AddFlags(os.Args[1:]); err != nil { ... }
+//	var settings Settings
+//	if errs := config.Load(&settings); errs != nil { ... }
+//
+// The result is validated with the decoder's options, and Origins tells which layer each value came from.
+type Config struct {
+	decoder *Decoder
+	layers  []Source
+	origins map[string]string
+}
+
+func NewConfig(d *Decoder) *Config {
+	return &Config{decoder: d}
+}
+
+// AddLayer adds src as a layer named name. The name is what Origins reports, and can be used in meta_from
+// once it is in the decoder's DecoderOptions.SourceNames.
+func (c *Config) AddLayer(name string, src Source) {
+	c.layers = append(c.layers, NewNamedSource(name, src))
+}
+
+// AddJSONFile adds a layer with the JSON object in the file at path. The layer is named path.
+func (c *Config) AddJSONFile(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !json.Valid(b) {
+		return fmt.Errorf("meta: config file %s is not valid JSON", path)
+	}
+	c.AddLayer(path, NewJSONSource(b))
+	return nil
+}
+
+// AddEnv adds a layer with the environment variables under prefix, see NewEnvSource. The layer is named SourceEnv.
+func (c *Config) AddEnv(prefix string) {
+	c.AddLayer(SourceEnv, NewEnvSource(c.decoder, prefix, os.Environ()))
+}
+
+// AddFlags adds a layer with command-line flags, eg os.Args[1:], parsed by the flag package with the flags from
+// Decoder.RegisterFlags: -db.max_conns=10, --db.max_conns 10 and a bare -debug all work. A flag can be repeated for a
+// slice. Arguments that aren't flags are an error. The layer is named LayerFlags.
+func (c *Config) AddFlags(args []string) error {
+	fs := flag.NewFlagSet(LayerFlags, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	values := c.decoder.RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("meta: %s", err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("meta: unexpected argument %q", fs.Arg(0))
+	}
+	c.AddLayer(LayerFlags, NewFormValueSource(values))
+	return nil
+}
+
+// Load decodes dest from the layers.
+func (c *Config) Load(dest interface{}) ErrorHash {
+	layers := make([]Source, len(c.layers))
+	for i, layer := range c.layers {
+		layers[len(layers)-1-i] = layer // merged sources take values from the first source that has them
+	}
+
+	var src Source = mergedSource(layers)
+	if len(c.decoder.Options.SourcePrecedence) > 0 {
+		src = orderSources(src, c.decoder.Options.SourcePrecedence)
+	}
+	if merged, ok := src.(mergedSource); ok {
+		layers = merged
+	}
+
+	c.origins = make(map[string]string)
+	c.decoder.origins(layers, "", c.origins)
+
+	return c.decoder.decodeSource(reflect.ValueOf(dest), src)
+}
+
+// Origins maps the dotted path of each field that got a value in the last Load, eg "db.max_conns", to the name of the
+// layer it came from, or LayerDefault. A slice of structs is reported as a whole, with the last layer that has it.
+func (c *Config) Origins() map[string]string {
+	return c.origins
+}
+
+// origins records in out which of layers, most important first, has each of d's fields.
+func (d *Decoder) origins(layers []Source, prefix string, out map[string]string) {
+	for _, dfield := range d.Fields {
+		key := joinPath(prefix, dfield.Name)
+
+		var fieldLayers []Source
+		for _, layer := range layers {
+			name, _ := sourceName(layer)
+			if len(dfield.From) == 0 || containsString(dfield.From, name) {
+				fieldLayers = append(fieldLayers, layer.Get(dfield.Name))
+			}
+		}
+
+		switch dfield.fieldCategory {
+		case categoryStruct:
+			dfield.StructDecoder.origins(fieldLayers, key, out)
+		case categoryValuer, categorySliceOfValues, categorySliceOfStructs:
+			for _, layer := range fieldLayers {
+				if !layer.Empty() {
+					out[key], _ = sourceName(layer)
+					break
+				}
+			}
+			if _, ok := out[key]; !ok && dfield.Default != "" {
+				out[key] = LayerDefault
+			}
+		}
+	}
+}
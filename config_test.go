@@ -0,0 +1,95 @@
+package meta
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type withConfig struct {
+	Name String `meta_default:"api"`
+	Port Int64  `meta_default:"8080" meta_min:"1"`
+	DB   struct {
+		Host     String `meta_required:"true"`
+		MaxConns Int64  `meta_default:"5"`
+	} `meta:"db"`
+	Tags  StringSlice
+	Debug Bool
+}
+
+var withConfigDecoder = NewDecoder(&withConfig{})
+
+func writeConfigFile(t *testing.T, contents string) string {
+	dir, err := ioutil.TempDir("", "meta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigLayers(t *testing.T) {
+	path := writeConfigFile(t, `{"port":9000,"db":{"host":"file-host","max_conns":20},"tags":["a"]}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	config := NewConfig(withConfigDecoder)
+	assertEqual(t, config.AddJSONFile(path), nil)
+	config.AddLayer(SourceEnv, NewEnvSource(config.decoder, "APP", []string{"APP_DB_HOST=env-host", "APP_PORT=9001"}))
+	assertEqual(t, config.AddFlags([]string{"--port", "9002", "-debug", "--tags=b", "-tags", "c"}), nil)
+
+	var settings withConfig
+	e := config.Load(&settings)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, settings.Name.Val, "api")
+	assertEqual(t, settings.Port.Val, int64(9002))
+	assertEqual(t, settings.DB.Host.Val, "env-host")
+	assertEqual(t, settings.DB.MaxConns.Val, int64(20))
+	assertEqual(t, settings.Tags.Val, []string{"b", "c"})
+	assertEqual(t, settings.Debug.Val, true)
+	assertEqual(t, settings.Port.Path, "port")
+	assertEqual(t, settings.DB.Host.Path, "db.host")
+	assertEqual(t, settings.DB.MaxConns.Path, "db.max_conns")
+
+	assertEqual(t, config.Origins(), map[string]string{
+		"name":         LayerDefault,
+		"port":         LayerFlags,
+		"db.host":      SourceEnv,
+		"db.max_conns": path,
+		"tags":         LayerFlags,
+		"debug":        LayerFlags,
+	})
+
+	// values from an earlier layer keep their paths
+	config = NewConfig(withConfigDecoder)
+	assertEqual(t, config.AddJSONFile(path), nil)
+	assertEqual(t, config.AddFlags([]string{"-debug"}), nil)
+	settings = withConfig{}
+	e = config.Load(&settings)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, settings.Port.Path, "port")
+	assertEqual(t, settings.DB.Host.Path, "db.host")
+}
+
+func TestConfigErrors(t *testing.T) {
+	config := NewConfig(withConfigDecoder)
+	assertEqual(t, config.AddFlags([]string{"--port=0", "--db.max_conns=x"}), nil)
+
+	var settings withConfig
+	e := config.Load(&settings)
+	assertEqual(t, e, ErrorHash{
+		"port": ErrMin,
+		"db":   ErrorHash{"host": ErrRequired, "max_conns": ErrInt},
+	})
+
+	assertEqual(t, config.AddFlags([]string{"--port", "80", "extra"}).Error(), `meta: unexpected argument "extra"`)
+	assertEqual(t, config.AddFlags([]string{"--db.max-conns=1"}).Error(), "meta: flag provided but not defined: -db.max-conns")
+
+	path := writeConfigFile(t, `{"port":`)
+	defer os.RemoveAll(filepath.Dir(path))
+	assert(t, config.AddJSONFile(path) != nil)
+	assert(t, config.AddJSONFile(path+".missing") != nil)
+}
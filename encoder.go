@@ -0,0 +1,254 @@
+package meta
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Encoder serializes a struct back into the wire format its Decoder accepts.
+// Fields use the same meta names, fields that aren't Present are omitted and null is only written for Null values.
+type Encoder struct {
+	decoder *Decoder
+}
+
+func NewEncoder(d *Decoder) *Encoder {
+	return &Encoder{decoder: d}
+}
+
+type presenter interface {
+	IsPresent() bool
+}
+
+type nuller interface {
+	IsNull() bool
+}
+
+// EncodeJSON returns the JSON encoding of src, which must be a struct or a pointer to a struct of the decoder's type.
+func (e *Encoder) EncodeJSON(src interface{}) ([]byte, error) {
+	m, err := e.encodeRoot(src)
+	if err != nil {
+		return nil, err
+	}
+	return MetaJson.Marshal(m)
+}
+
+// EncodeValues returns src as url.Values with dotted keys, eg {"a.0": ..., "c.d": ...}, like Decoder.DecodeValues expects.
+func (e *Encoder) EncodeValues(src interface{}) (url.Values, error) {
+	m, err := e.encodeRoot(src)
+	if err != nil {
+		return nil, err
+	}
+	values := make(url.Values)
+	flattenValues(values, "", m)
+	return values, nil
+}
+
+func (e *Encoder) encodeRoot(src interface{}) (map[string]interface{}, error) {
+	srcValue := reflect.Indirect(reflect.ValueOf(src))
+	if srcValue.Type() != e.decoder.StructType {
+		panic(fmt.Sprintf("expect type %s, got %s", e.decoder.StructType, srcValue.Type()))
+	}
+	return encodeStruct(e.decoder, srcValue)
+}
+
+// encodeStruct returns a tree of map[string]interface{}, []interface{} and leaf values for a struct value.
+func encodeStruct(d *Decoder, structValue reflect.Value) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+	var allFields reflect.Value
+
+	for _, dfield := range d.Fields {
+		fieldValue := structValue.FieldByIndex(dfield.fieldIndex)
+
+		switch dfield.fieldCategory {
+		case categoryValuer:
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					continue
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			v, ok, err := encodeValue(fieldValue, dfield.Options)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				out[dfield.Name] = v
+			}
+		case categoryStruct:
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					continue
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			m, err := encodeStruct(dfield.StructDecoder, fieldValue)
+			if err != nil {
+				return nil, err
+			}
+			// A pointer to a struct is meaningful even if it's empty, but a struct value with nothing in it wasn't present.
+			if len(m) > 0 || dfield.fieldKind == reflect.Ptr {
+				out[dfield.Name] = m
+			}
+		case categorySliceOfValues:
+			if fieldValue.Len() == 0 {
+				continue
+			}
+			slice := make([]interface{}, fieldValue.Len())
+			for i := range slice {
+				el := fieldValue.Index(i)
+				if el.Kind() == reflect.Ptr {
+					if el.IsNil() {
+						continue
+					}
+					el = el.Elem()
+				}
+				// Keep the position of elements that aren't present so indices still line up.
+				v, _, err := encodeValue(el, dfield.Options)
+				if err != nil {
+					return nil, err
+				}
+				slice[i] = v
+			}
+			out[dfield.Name] = slice
+		case categorySliceOfStructs:
+			if fieldValue.Len() == 0 {
+				continue
+			}
+			slice := make([]interface{}, fieldValue.Len())
+			for i := range slice {
+				el := fieldValue.Index(i)
+				if el.Kind() == reflect.Ptr {
+					if el.IsNil() {
+						continue
+					}
+					el = el.Elem()
+				}
+				m, err := encodeStruct(dfield.StructDecoder, el)
+				if err != nil {
+					return nil, err
+				}
+				slice[i] = m
+			}
+			out[dfield.Name] = slice
+		case categoryAllFieldsMap, categoryRestFieldsMap:
+			allFields = fieldValue
+		}
+	}
+
+	// Fields that the struct knows about take precedence over the catch-all map.
+	if allFields.IsValid() {
+		for _, key := range allFields.MapKeys() {
+			if _, ok := out[key.String()]; !ok {
+				out[key.String()] = allFields.MapIndex(key).Interface()
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// encodeValue returns the leaf value for a Valuer. ok is false if the value isn't present and should be omitted.
+func encodeValue(v reflect.Value, options interface{}) (leaf interface{}, ok bool, err error) {
+	i := v.Interface()
+	if p, isPresenter := i.(presenter); isPresenter && !p.IsPresent() {
+		return nil, false, nil
+	}
+	if n, isNuller := i.(nuller); isNuller && n.IsNull() {
+		return nil, true, nil
+	}
+
+	switch value := i.(type) {
+	case String:
+		return value.Val, true, nil
+	case Int64:
+		return value.Val, true, nil
+	case Uint64:
+		return value.Val, true, nil
+	case Float64:
+		return value.Val, true, nil
+	case Bool:
+		return value.Val, true, nil
+	case Bytes:
+		return base64.StdEncoding.EncodeToString(value.Val), true, nil
+	case Time:
+		return value.Val.Format(encodeTimeFormat(options)), true, nil
+	case Int64Slice:
+		return value.Val, len(value.Val) > 0, nil
+	case StringSlice:
+		return value.Val, len(value.Val) > 0, nil
+	}
+
+	// Some other Valuer: without Presence, null means there's nothing to encode.
+	raw, err := MetaJson.Marshal(i)
+	if err != nil {
+		return nil, false, err
+	}
+	if string(raw) == string(nullString) {
+		return nil, false, nil
+	}
+	return json.RawMessage(raw), true, nil
+}
+
+// encodeTimeFormat is the first layout the field accepts that can be used for output.
+// RFC3339 is written as RFC3339Nano so that values round trip.
+func encodeTimeFormat(options interface{}) string {
+	if opts, ok := options.(*TimeOptions); ok {
+		for _, format := range opts.Format {
+			if format == time.RFC3339 {
+				// RFC3339Nano parses as RFC3339 too, and keeps sub-second precision.
+				return time.RFC3339Nano
+			}
+			if format != "expression" {
+				return format
+			}
+		}
+	}
+	return time.RFC3339Nano
+}
+
+func flattenValues(values url.Values, prefix string, v interface{}) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, el := range value {
+			flattenValues(values, joinPath(prefix, k), el)
+		}
+	case []interface{}:
+		for i, el := range value {
+			flattenValues(values, joinPath(prefix, strconv.Itoa(i)), el)
+		}
+	default:
+		values.Set(prefix, formValue(value))
+	}
+}
+
+// formValue is the text of a leaf as it would appear in a form. Slice types are comma separated, which is what their FormValue expects.
+func formValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []string:
+		return strings.Join(value, ",")
+	case []int64:
+		strs := make([]string, len(value))
+		for i, n := range value {
+			strs[i] = strconv.FormatInt(n, 10)
+		}
+		return strings.Join(strs, ",")
+	case json.RawMessage:
+		var s string
+		if err := MetaJson.Unmarshal(value, &s); err == nil {
+			return s
+		}
+		return string(value)
+	}
+	raw, _ := MetaJson.Marshal(v)
+	return string(raw)
+}
@@ -0,0 +1,103 @@
+package meta
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+type encoded struct {
+	A []String
+	B String `meta_null:"true"`
+	C struct {
+		D Int64
+		E Float64
+	}
+	F *struct {
+		G Bool
+	}
+	H []*struct {
+		I Time `meta_format:"2006-01-02"`
+	}
+	J StringSlice
+	K String `meta:"kay"`
+}
+
+var encodedDecoder = NewDecoder(&encoded{})
+
+func TestEncodeJSON(t *testing.T) {
+	var inputs encoded
+	in := `{"a":["x","y"],"b":null,"c":{"d":1,"e":1.5},"f":{},"h":[{"i":"2015-01-02"}],"j":["p","q"]}`
+	e := encodedDecoder.DecodeJSON(&inputs, []byte(in))
+	assertEqual(t, e, ErrorHash(nil))
+
+	out, err := NewEncoder(encodedDecoder).EncodeJSON(&inputs)
+	assert(t, err == nil)
+	assertEqual(t, string(out), in)
+
+	// omits fields that aren't present
+	out, err = NewEncoder(encodedDecoder).EncodeJSON(encoded{K: NewString("k")})
+	assert(t, err == nil)
+	assertEqual(t, string(out), `{"kay":"k"}`)
+}
+
+func TestEncodeValues(t *testing.T) {
+	inputs := encoded{
+		A: []String{NewString("x"), NewString("y")},
+		B: String{Nullity: Nullity{true}, Presence: Presence{true}},
+		H: []*struct {
+			I Time `meta_format:"2006-01-02"`
+		}{{I: NewTime(time.Date(2015, 1, 2, 0, 0, 0, 0, time.UTC))}},
+		J: StringSlice{Val: []string{"p", "q"}},
+	}
+	inputs.C.D = NewInt64(1)
+
+	values, err := NewEncoder(encodedDecoder).EncodeValues(&inputs)
+	assert(t, err == nil)
+	assertEqual(t, values, url.Values{
+		"a.0":   {"x"},
+		"a.1":   {"y"},
+		"b":     {""},
+		"c.d":   {"1"},
+		"h.0.i": {"2015-01-02"},
+		"j":     {"p,q"},
+	})
+
+	// and it decodes back to the same thing
+	var decoded encoded
+	e := encodedDecoder.DecodeValues(&decoded, values)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, decoded.A[1].Val, "y")
+	assertEqual(t, decoded.B.Null, true)
+	assertEqual(t, decoded.C.D.Val, int64(1))
+	assertEqual(t, decoded.H[0].I.Val, inputs.H[0].I.Val)
+	assertEqual(t, decoded.J.Val, []string{"p", "q"})
+}
+
+func TestEncodeTimeRoundTrip(t *testing.T) {
+	type withTime struct {
+		T Time
+	}
+	d := NewDecoder(&withTime{})
+	in := withTime{T: NewTime(time.Date(2015, 1, 2, 3, 4, 5, 123456789, time.UTC))}
+
+	out, err := NewEncoder(d).EncodeJSON(&in)
+	assert(t, err == nil)
+	assertEqual(t, string(out), `{"t":"2015-01-02T03:04:05.123456789Z"}`)
+
+	var decoded withTime
+	e := d.DecodeJSON(&decoded, out)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, decoded.T.Val, in.T.Val)
+}
+
+func TestEncodeMetaStar(t *testing.T) {
+	var inputs withMetaStar
+	e := withMetaStarDecoder.DecodeJSON(&inputs, []byte(`{"a_field": "A field", "cf_numeric_field": 12}`))
+	assertEqual(t, e, ErrorHash(nil))
+	inputs.AField = NewString("changed")
+
+	out, err := NewEncoder(withMetaStarDecoder).EncodeJSON(&inputs)
+	assert(t, err == nil)
+	assertEqual(t, string(out), `{"a_field":"changed","cf_numeric_field":12}`)
+}
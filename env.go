@@ -0,0 +1,159 @@
+package meta
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SourceEnv is the name of NewEnvSource's sources.
+const SourceEnv = "env"
+
+// DecodeEnv decodes config from the environment with NewEnvSource(d, prefix, os.Environ()).
+// Every problem is in the ErrorHash, so a bad deploy can fail at startup with all of them listed.
+func (d *Decoder) DecodeEnv(dest interface{}, prefix string) ErrorHash {
+	return d.decodeSource(reflect.ValueOf(dest), NewEnvSource(d, prefix, os.Environ()))
+}
+
+// NewEnvSource reads environment variables, given as "KEY=value" like os.Environ. Nested fields are upper snake case
+// joined by underscores after the prefix, so with the prefix "APP", DB.MaxConns (db.max_conns) is APP_DB_MAX_CONNS.
+// An empty prefix means no prefix.
+//
+// Slices are either comma separated, APP_HOSTS=a,b, or indexed, APP_HOSTS_0=a and APP_HOSTS_1=b; a slice of structs
+// is always indexed, eg APP_SERVERS_0_PORT. Variable names don't say where one field ends and the next begins,
+// so ValueMap is nil: env vars are never unknown fields and never end up in a meta:"*" map. It's named SourceEnv.
+//
+// d's fields say which variables are values and which are parts of nested structs, so that eg APP_PORT_RANGE doesn't
+// count as a value for a field Port.
+func NewEnvSource(d *Decoder, prefix string, environ []string) Source {
+	if prefix != "" {
+		prefix = strings.ToUpper(prefix) + "_"
+	}
+
+	vars := make(map[string]string)
+	for _, kv := range environ {
+		i := strings.IndexByte(kv, '=')
+		if i < 0 || !strings.HasPrefix(kv[:i], prefix) {
+			continue
+		}
+		vars[kv[len(prefix):i]] = kv[i+1:]
+	}
+	return NewNamedSource(SourceEnv, &envSource{vars: vars, known: envNames(d)})
+}
+
+// envNames matches the variable names of d's fields, without the prefix. Slice indices are any number, and an index
+// can follow any field, for APP_HOSTS_0 with a StringSlice.
+func envNames(d *Decoder) *regexp.Regexp {
+	var patterns []string
+	for key := range d.FormSchema().Properties {
+		parts := strings.Split(key, ".")
+		for i, part := range parts {
+			if _, err := strconv.Atoi(part); err == nil {
+				parts[i] = "[0-9]+"
+			} else {
+				parts[i] = regexp.QuoteMeta(strings.ToUpper(part))
+			}
+		}
+		patterns = append(patterns, strings.Join(parts, "_"))
+	}
+	sort.Strings(patterns)
+	return regexp.MustCompile("^(?:" + strings.Join(patterns, "|") + ")(?:_[0-9]+)?$")
+}
+
+// envSource is the variables under name, which is "" at the root. split is set for one element of a comma separated slice.
+// known matches the names of variables that are fields.
+type envSource struct {
+	vars  map[string]string
+	known *regexp.Regexp
+	name  string
+	path  string
+	split *string
+}
+
+func (s *envSource) Get(key string) Source {
+	if s.split != nil {
+		return &emptySource{}
+	}
+
+	name := strings.ToUpper(key)
+	if s.name != "" {
+		name = s.name + "_" + name
+	}
+	child := &envSource{vars: s.vars, known: s.known, name: name, path: joinPath(s.path, key)}
+	if !child.Empty() {
+		return child
+	}
+
+	// APP_HOSTS=a,b is the same as APP_HOSTS_0=a APP_HOSTS_1=b
+	if index, err := strconv.Atoi(key); err == nil && index >= 0 {
+		if value, ok := s.vars[s.name]; ok && s.name != "" && value != "" {
+			if values := strings.Split(value, ","); index < len(values) {
+				child.split = &values[index]
+				return child
+			}
+		}
+	}
+	return &emptySource{}
+}
+
+func (s *envSource) Value(i interface{}) Errorable {
+	switch v := i.(type) {
+	case *interface{}:
+		if s.split != nil {
+			*v = *s.split
+		} else if value, ok := s.vars[s.name]; ok && s.name != "" {
+			*v = value
+		} else if values := s.indexed(); len(values) > 0 {
+			*v = values // APP_HOSTS_0, APP_HOSTS_1 for a StringSlice
+		}
+	default:
+		return ErrBlank
+	}
+	return nil
+}
+
+// indexed returns the values of NAME_0, NAME_1, ... up to the first one that isn't set.
+func (s *envSource) indexed() []interface{} {
+	var values []interface{}
+	for i := 0; ; i++ {
+		value, ok := s.vars[s.name+"_"+strconv.Itoa(i)]
+		if !ok {
+			return values
+		}
+		values = append(values, value)
+	}
+}
+
+func (s *envSource) Empty() bool {
+	if s.split != nil {
+		return false
+	}
+	if s.name == "" {
+		return len(s.vars) == 0
+	}
+	if _, ok := s.vars[s.name]; ok {
+		return false
+	}
+	// An indexed element of a slice, or a field of a nested struct
+	for name := range s.vars {
+		if strings.HasPrefix(name, s.name+"_") && s.known.MatchString(name) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *envSource) ValueMap() map[string]interface{} {
+	return nil
+}
+
+func (s *envSource) Malformed() bool {
+	return false
+}
+
+func (s *envSource) Path() string {
+	return s.path
+}
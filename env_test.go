@@ -0,0 +1,107 @@
+package meta
+
+import (
+	"os"
+	"testing"
+)
+
+type withEnv struct {
+	Name String `meta_required:"true"`
+	DB   struct {
+		MaxConns Int64 `meta_required:"true" meta_min:"1"`
+		Hosts    StringSlice
+	} `meta:"db"`
+	Ports   []Int64
+	Servers []struct {
+		Port Int64
+	}
+	Debug Bool
+}
+
+var withEnvDecoder = NewDecoder(&withEnv{})
+
+func TestEnvSource(t *testing.T) {
+	var inputs withEnv
+	e := withEnvDecoder.DecodeSource(&inputs, NewEnvSource(withEnvDecoder, "app", []string{
+		"APP_NAME=api",
+		"APP_DB_MAX_CONNS=10",
+		"APP_DB_HOSTS=a,b",
+		"APP_PORTS=80,443",
+		"APP_SERVERS_0_PORT=8080",
+		"APP_SERVERS_1_PORT=8081",
+		"APP_DEBUG=true",
+		"NAME=other",
+		"PATH=/bin",
+	}))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.Name.Val, "api")
+	assertEqual(t, inputs.DB.MaxConns.Val, int64(10))
+	assertEqual(t, inputs.DB.MaxConns.Path, "db.max_conns")
+	assertEqual(t, inputs.DB.Hosts.Val, []string{"a", "b"})
+	assertEqual(t, len(inputs.Ports), 2)
+	if len(inputs.Ports) == 2 {
+		assertEqual(t, inputs.Ports[1].Val, int64(443))
+	}
+	assertEqual(t, len(inputs.Servers), 2)
+	if len(inputs.Servers) == 2 {
+		assertEqual(t, inputs.Servers[1].Port.Val, int64(8081))
+	}
+	assertEqual(t, inputs.Debug.Val, true)
+}
+
+func TestEnvSourceIndexed(t *testing.T) {
+	var inputs withEnv
+	e := withEnvDecoder.DecodeSource(&inputs, NewEnvSource(withEnvDecoder, "", []string{
+		"NAME=api",
+		"DB_MAX_CONNS=1",
+		"DB_HOSTS_0=a",
+		"DB_HOSTS_1=b",
+		"PORTS_0=80",
+		"PORTS_1=443",
+	}))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.DB.Hosts.Val, []string{"a", "b"})
+	assertEqual(t, len(inputs.Ports), 2)
+}
+
+func TestEnvSourceErrors(t *testing.T) {
+	var inputs withEnv
+	e := withEnvDecoder.DecodeSource(&inputs, NewEnvSource(withEnvDecoder, "APP", []string{
+		"APP_DB_MAX_CONNS=0",
+		"APP_PORTS=80,x",
+	}))
+	assertEqual(t, e, ErrorHash{
+		"name":  ErrRequired,
+		"db":    ErrorHash{"max_conns": ErrMin},
+		"ports": ErrorSlice{nil, ErrInt},
+	})
+}
+
+func TestEnvSourceSiblingPrefix(t *testing.T) {
+	var inputs struct {
+		Port Int64  `meta_default:"8080"`
+		Host String `meta_required:"true"`
+		DB   *struct {
+			Name String
+		} `meta:"db"`
+	}
+	d := NewDecoder(&inputs)
+	e := d.DecodeSource(&inputs, NewEnvSource(d, "APP", []string{"APP_PORT_RANGE=1-2", "APP_HOST_NAME=x", "APP_DB_USER=u"}))
+	assertEqual(t, e, ErrorHash{"host": ErrRequired})
+	assertEqual(t, inputs.Port.Val, int64(8080))
+	assertEqual(t, inputs.Port.Present, true)
+	assert(t, inputs.DB == nil)
+}
+
+func TestDecodeEnv(t *testing.T) {
+	os.Setenv("METATEST_NAME", "api")
+	os.Setenv("METATEST_DB_MAX_CONNS", "5")
+	defer os.Unsetenv("METATEST_NAME")
+	defer os.Unsetenv("METATEST_DB_MAX_CONNS")
+
+	var inputs withEnv
+	e := withEnvDecoder.DecodeEnv(&inputs, "metatest")
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.Name.Val, "api")
+	assertEqual(t, inputs.DB.MaxConns.Val, int64(5))
+}
@@ -1,5 +1,7 @@
 package meta
 
+import "strings"
+
 type Errorable interface {
 	// Errorable should be a go error
 	error
@@ -59,23 +61,65 @@ func NewHash(key, value string) ErrorHash {
 }
 
 var (
-	ErrMalformed  = ErrorAtom("malformed_json")
-	ErrBlank      = ErrorAtom("blank")
-	ErrRequired   = ErrorAtom("required")
-	ErrMinRunes   = ErrorAtom("min_runes")
-	ErrMaxRunes   = ErrorAtom("max_runes")
-	ErrMaxBytes   = ErrorAtom("max_bytes")
-	ErrUtf8       = ErrorAtom("utf8")
-	ErrBool       = ErrorAtom("bool")
-	ErrTime       = ErrorAtom("time")
-	ErrInt        = ErrorAtom("int")
-	ErrIntRange   = ErrorAtom("int_range")
-	ErrString     = ErrorAtom("string")
-	ErrFloat      = ErrorAtom("float")
-	ErrFloatRange = ErrorAtom("float_range")
-	ErrMin        = ErrorAtom("min")
-	ErrMax        = ErrorAtom("max")
-	ErrIn         = ErrorAtom("in")
-	ErrMinLength  = ErrorAtom("min_length")
-	ErrMaxLength  = ErrorAtom("max_length")
+	ErrMalformed     = ErrorAtom("malformed_json")
+	ErrBlank         = ErrorAtom("blank")
+	ErrRequired      = ErrorAtom("required")
+	ErrMinRunes      = ErrorAtom("min_runes")
+	ErrMaxRunes      = ErrorAtom("max_runes")
+	ErrMaxBytes      = ErrorAtom("max_bytes")
+	ErrUtf8          = ErrorAtom("utf8")
+	ErrBool          = ErrorAtom("bool")
+	ErrTime          = ErrorAtom("time")
+	ErrInt           = ErrorAtom("int")
+	ErrIntRange      = ErrorAtom("int_range")
+	ErrString        = ErrorAtom("string")
+	ErrFloat         = ErrorAtom("float")
+	ErrFloatRange    = ErrorAtom("float_range")
+	ErrBytes         = ErrorAtom("bytes")
+	ErrMin           = ErrorAtom("min")
+	ErrMax           = ErrorAtom("max")
+	ErrIn            = ErrorAtom("in")
+	ErrMinLength     = ErrorAtom("min_length")
+	ErrMaxLength     = ErrorAtom("max_length")
+	ErrPattern       = ErrorAtom("pattern")
+	ErrUnknownField  = ErrorAtom("unknown_field")
+	ErrBodyTooLarge  = ErrorAtom("body_too_large")
+	ErrMaxDepth      = ErrorAtom("max_depth")
+	ErrMaxElements   = ErrorAtom("max_elements")
+	ErrMaxKeys       = ErrorAtom("max_keys")
+	ErrTooManyErrors = ErrorAtom("too_many_errors")
+	ErrConflict      = ErrorAtom("conflict")
+
+	ErrUnsupportedMediaType = ErrorAtom("unsupported_media_type")
+	ErrMalformedBody        = ErrorAtom("malformed_body")
+	ErrFile                 = ErrorAtom("file")
+	ErrMaxSize              = ErrorAtom("max_size")
+	ErrContentType          = ErrorAtom("content_type")
+	ErrSparseIndex          = ErrorAtom("sparse_index")
+	ErrMaxIndex             = ErrorAtom("max_index")
 )
+
+// DefinitionError is a problem with a struct definition that was found while building a Decoder, eg a malformed tag.
+// Path is the dotted meta path of the offending field; elements of a slice of structs are written as "items[].name".
+type DefinitionError struct {
+	Path    string
+	Message string
+}
+
+func (e *DefinitionError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// DefinitionErrors is every DefinitionError found in a struct definition.
+type DefinitionErrors []*DefinitionError
+
+func (es DefinitionErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return "meta: invalid struct definition: " + strings.Join(msgs, "; ")
+}
@@ -0,0 +1,142 @@
+package meta
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//
+// File
+//
+
+// File is an uploaded file from a multipart form, decoded with DecodeRequest.
+// ContentType is sniffed from the file's contents rather than taken from its part header.
+type File struct {
+	Val         *multipart.FileHeader
+	ContentType string
+	Presence
+	Path string
+}
+
+type FileOptions struct {
+	Required       bool
+	MaxSizePresent bool
+	MaxSize        int64
+	ContentTypes   []string // eg "image/png" or "image/*"
+}
+
+func (f *File) ParseOptions(tag reflect.StructTag) interface{} {
+	opts := &FileOptions{}
+
+	if tag.Get("meta_required") == "true" {
+		opts.Required = true
+	}
+
+	if maxSizeString := tag.Get("meta_max_size"); maxSizeString != "" {
+		maxSize, err := strconv.ParseInt(maxSizeString, 10, 64)
+		if err != nil {
+			panic(err.Error())
+		}
+
+		opts.MaxSizePresent = true
+		opts.MaxSize = maxSize
+	}
+
+	if contentTypes := tag.Get("meta_content_types"); contentTypes != "" {
+		for _, s := range strings.Split(contentTypes, ",") {
+			opts.ContentTypes = append(opts.ContentTypes, strings.TrimSpace(s))
+		}
+	}
+
+	return opts
+}
+
+func (f *File) JSONValue(path string, i interface{}, options interface{}) Errorable {
+	f.Path = path
+	opts := options.(*FileOptions)
+
+	switch value := i.(type) {
+	case nil:
+		if opts.Required {
+			return ErrBlank
+		}
+		return nil
+	case []interface{}:
+		if len(value) == 1 {
+			return f.JSONValue(path, value[0], options)
+		}
+		if len(value) == 0 && opts.Required {
+			return ErrBlank
+		}
+	case *multipart.FileHeader:
+		return f.validateValue(value, opts)
+	}
+
+	return ErrFile
+}
+
+func (f *File) validateValue(fh *multipart.FileHeader, opts *FileOptions) Errorable {
+	if opts.MaxSizePresent && fh.Size > opts.MaxSize {
+		return ErrMaxSize
+	}
+
+	contentType, err := sniffContentType(fh)
+	if err != nil {
+		return ErrFile
+	}
+
+	if len(opts.ContentTypes) > 0 {
+		found := false
+		for _, allowed := range opts.ContentTypes {
+			if allowed == contentType || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(allowed, "*"))) {
+				found = true
+			}
+		}
+		if !found {
+			return ErrContentType
+		}
+	}
+
+	f.Val = fh
+	f.ContentType = contentType
+	f.Present = true
+	return nil
+}
+
+// sniffContentType returns the media type of the start of a file, without parameters like charset.
+func sniffContentType(fh *multipart.FileHeader) (string, error) {
+	file, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	if err != nil {
+		return "", err
+	}
+	return mediaType, nil
+}
+
+// Open opens the uploaded file.
+func (f File) Open() (multipart.File, error) {
+	return f.Val.Open()
+}
+
+func (f File) MarshalJSON() ([]byte, error) {
+	if f.Present {
+		return MetaJson.Marshal(f.Val.Filename)
+	}
+	return nullString, nil
+}
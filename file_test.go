@@ -0,0 +1,97 @@
+package meta
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type upload struct {
+	name     string
+	filename string
+	content  []byte
+}
+
+func multipartRequest(t *testing.T, fields map[string]string, uploads ...upload) *http.Request {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for k, v := range fields {
+		w.WriteField(k, v)
+	}
+	for _, u := range uploads {
+		// the part's Content-Type header is always application/octet-stream; File ignores it
+		part, err := w.CreateFormFile(u.name, u.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(u.content)
+	}
+	w.Close()
+
+	req := httptest.NewRequest("POST", "/", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+type withFile struct {
+	Title  String
+	Avatar File   `meta_required:"true" meta_max_size:"100" meta_content_types:"image/*"`
+	Docs   []File `meta_content_types:"text/plain"`
+}
+
+var withFileDecoder = NewDecoder(&withFile{})
+
+func TestFileSuccess(t *testing.T) {
+	req := multipartRequest(t, map[string]string{"title": "hi"},
+		upload{"avatar", "me.png", pngHeader},
+		upload{"docs", "a.txt", []byte("hello")},
+		upload{"docs", "b.txt", []byte("world")},
+	)
+
+	var inputs withFile
+	e := withFileDecoder.DecodeRequest(&inputs, req)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.Title.Val, "hi")
+	assertEqual(t, inputs.Avatar.Present, true)
+	assertEqual(t, inputs.Avatar.Val.Filename, "me.png")
+	assertEqual(t, inputs.Avatar.ContentType, "image/png")
+	assertEqual(t, inputs.Avatar.Path, "avatar")
+	assertEqual(t, len(inputs.Docs), 2)
+	if len(inputs.Docs) == 2 {
+		assertEqual(t, inputs.Docs[1].Val.Filename, "b.txt")
+		assertEqual(t, inputs.Docs[1].ContentType, "text/plain")
+	}
+
+	f, err := inputs.Avatar.Open()
+	assert(t, err == nil)
+	f.Close()
+}
+
+func TestFileErrors(t *testing.T) {
+	var inputs withFile
+	e := withFileDecoder.DecodeRequest(&inputs, multipartRequest(t, map[string]string{"title": "hi"}))
+	assertEqual(t, e, ErrorHash{"avatar": ErrRequired})
+	assertEqual(t, inputs.Avatar.Present, false)
+
+	inputs = withFile{}
+	e = withFileDecoder.DecodeRequest(&inputs, multipartRequest(t, nil,
+		upload{"avatar", "me.png", []byte("not really a png")},
+		upload{"docs", "a.txt", pngHeader},
+	))
+	assertEqual(t, e, ErrorHash{"avatar": ErrContentType, "docs": ErrorSlice{ErrContentType}})
+
+	inputs = withFile{}
+	e = withFileDecoder.DecodeRequest(&inputs, multipartRequest(t, nil,
+		upload{"avatar", "me.png", append(pngHeader, make([]byte, 100)...)},
+	))
+	assertEqual(t, e, ErrorHash{"avatar": ErrMaxSize})
+
+	// a file field can't be filled from a plain value
+	inputs = withFile{}
+	e = withFileDecoder.DecodeRequest(&inputs, multipartRequest(t, map[string]string{"avatar": "me.png"}))
+	assertEqual(t, e, ErrorHash{"avatar": ErrFile})
+}
@@ -0,0 +1,104 @@
+package meta
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// RegisterFlags defines a flag on fs for every key that d decodes (see FormSchema), and returns the values of the flags
+// that are set, to decode with DecodeValues once fs has been parsed. A field DB.MaxConns is -db.max_conns, the doc tag is
+// the flag's usage and meta_default is its default. A slice of values, or an Int64Slice or StringSlice, can be repeated:
+// -tags=a -tags=b. Slices of structs aren't flags. The metaflag package and Config.AddFlags are built on it.
+func (d *Decoder) RegisterFlags(fs *flag.FlagSet) url.Values {
+	values := make(url.Values)
+
+	schema := d.FormSchema()
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop := schema.Properties[name]
+		key, repeated := flagKey(name)
+		if key == "" {
+			continue
+		}
+		v := &flagValue{
+			key:      key,
+			values:   values,
+			repeated: repeated || hasSchemaType(prop, "array"),
+			isBool:   hasSchemaType(prop, "boolean"),
+		}
+		if prop.Default != nil {
+			v.def = fmt.Sprint(prop.Default)
+		}
+		fs.Var(v, key, prop.Description)
+	}
+	return values
+}
+
+// flagKey returns the flag for a FormSchema key: "tags.0", a slice of values, is the repeated flag "tags".
+// Keys inside a slice of structs, eg "items.0.name", aren't flags.
+func flagKey(name string) (string, bool) {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part == "0" {
+			if i != len(parts)-1 {
+				return "", false
+			}
+			return strings.Join(parts[:i], "."), true
+		}
+	}
+	return name, false
+}
+
+func hasSchemaType(schema *Schema, typ string) bool {
+	switch t := schema.Type.(type) {
+	case string:
+		return t == typ
+	case []string:
+		for _, s := range t {
+			if s == typ {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flagValue is a flag.Value that collects its flag's values for decoding.
+type flagValue struct {
+	key      string
+	values   url.Values
+	repeated bool
+	isBool   bool
+	def      string
+}
+
+func (v *flagValue) String() string {
+	if v == nil || v.values == nil {
+		return ""
+	}
+	if values, ok := v.values[v.key]; ok {
+		return strings.Join(values, ",")
+	}
+	return v.def
+}
+
+func (v *flagValue) Set(s string) error {
+	if v.repeated {
+		v.values.Add(v.key, s)
+	} else {
+		v.values.Set(v.key, s)
+	}
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
@@ -3,6 +3,7 @@ package meta
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
@@ -94,6 +95,10 @@ func (f *Float64) JSONValue(path string, i interface{}, options interface{}) Err
 	switch value := i.(type) {
 	case float64:
 		return f.validateValue(value, options)
+	case int64:
+		return f.validateValue(float64(value), options)
+	case uint64:
+		return f.validateValue(float64(value), options)
 	case json.Number:
 		return f.FormValue(string(value), options)
 	case string:
@@ -167,9 +172,72 @@ func (i Float64) Value() (driver.Value, error) {
 	return nil, nil
 }
 
+// Scan implements sql.Scanner. A NULL column sets Null.
+func (i *Float64) Scan(src interface{}) error {
+	i.Val, i.Null, i.Present = 0, false, true
+	switch value := src.(type) {
+	case nil:
+		i.Null = true
+		return nil
+	case float64:
+		i.Val = value
+		return nil
+	case int64:
+		i.Val = float64(value)
+		return nil
+	case string:
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			i.Val = n
+			return nil
+		}
+	case []byte:
+		if n, err := strconv.ParseFloat(string(value), 64); err == nil {
+			i.Val = n
+			return nil
+		}
+	}
+	i.Present = false
+	return fmt.Errorf("meta: cannot scan %T(%v) into Float64", src, src)
+}
+
 func (i Float64) MarshalJSON() ([]byte, error) {
 	if i.Present && !i.Null {
 		return MetaJson.Marshal(i.Val)
 	}
 	return nullString, nil
 }
+
+// UnmarshalJSON implements json.Unmarshaler using the default options. null sets Null, so values round trip through MarshalJSON.
+func (i *Float64) UnmarshalJSON(data []byte) error {
+	*i = Float64{}
+	if isJSONNull(data) {
+		i.Present = true
+		i.Null = true
+		return nil
+	}
+	opts := i.ParseOptions("")
+	return unmarshalJSONValue(i, data, opts)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using the default options.
+func (i *Float64) UnmarshalText(text []byte) error {
+	*i = Float64{}
+	opts := i.ParseOptions("")
+	if err := i.FormValue(string(text), opts); err != nil {
+		return err
+	}
+	return nil
+}
+
+// String implements flag.Value.
+func (i *Float64) String() string {
+	if !i.Present || i.Null {
+		return ""
+	}
+	return strconv.FormatFloat(i.Val, 'g', -1, 64)
+}
+
+// Set implements flag.Value using the default options.
+func (i *Float64) Set(value string) error {
+	return i.UnmarshalText([]byte(value))
+}
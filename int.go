@@ -3,6 +3,8 @@ package meta
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
@@ -169,6 +171,11 @@ func (n *Int64) JSONValue(path string, i interface{}, options interface{}) Error
 		return n.validateValue(int64(value), options)
 	case int64:
 		return n.validateValue(value, options)
+	case uint64:
+		if value > math.MaxInt64 {
+			return ErrIntRange
+		}
+		return n.validateValue(int64(value), options)
 	case json.Number:
 		return n.FormValue(string(value), options)
 	case string:
@@ -244,8 +251,14 @@ func (n *Uint64) JSONValue(path string, i interface{}, options interface{}) Erro
 
 	switch value := i.(type) {
 	case int:
+		if value < 0 {
+			return ErrInt // like "-1"
+		}
 		return n.validateValue(uint64(value), options)
 	case int64:
+		if value < 0 {
+			return ErrInt // like "-1"
+		}
 		return n.validateValue(uint64(value), options)
 	case uint64:
 		return n.validateValue(value, options)
@@ -324,14 +337,74 @@ func (i Int64) Value() (driver.Value, error) {
 	return nil, nil
 }
 
-// NOTE: I know I am casting uints to int64's. This is per Go's docs, which does NOT list uint64 as a viable type. Unsure what that means for a large Uint64.
+// driver.Value does not include uint64, so values that fit in an int64 are returned as an int64
+// and anything larger is returned as its decimal string rather than silently wrapping around.
 func (i Uint64) Value() (driver.Value, error) {
 	if i.Present && !i.Null {
+		if i.Val > math.MaxInt64 {
+			return strconv.FormatUint(i.Val, 10), nil
+		}
 		return int64(i.Val), nil
 	}
 	return nil, nil
 }
 
+// Scan implements sql.Scanner. A NULL column sets Null.
+func (i *Int64) Scan(src interface{}) error {
+	i.Val, i.Null, i.Present = 0, false, true
+	switch value := src.(type) {
+	case nil:
+		i.Null = true
+		return nil
+	case int64:
+		i.Val = value
+		return nil
+	case string:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			i.Val = n
+			return nil
+		}
+	case []byte:
+		if n, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+			i.Val = n
+			return nil
+		}
+	}
+	i.Present = false
+	return fmt.Errorf("meta: cannot scan %T(%v) into Int64", src, src)
+}
+
+// Scan implements sql.Scanner. A NULL column sets Null.
+// Values above math.MaxInt64 can be scanned from their decimal string (see Value) or from a uint64 if the driver returns one.
+func (i *Uint64) Scan(src interface{}) error {
+	i.Val, i.Null, i.Present = 0, false, true
+	switch value := src.(type) {
+	case nil:
+		i.Null = true
+		return nil
+	case int64:
+		if value >= 0 {
+			i.Val = uint64(value)
+			return nil
+		}
+	case uint64:
+		i.Val = value
+		return nil
+	case string:
+		if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+			i.Val = n
+			return nil
+		}
+	case []byte:
+		if n, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			i.Val = n
+			return nil
+		}
+	}
+	i.Present = false
+	return fmt.Errorf("meta: cannot scan %T(%v) into Uint64", src, src)
+}
+
 func (i Int64) MarshalJSON() ([]byte, error) {
 	if i.Present && !i.Null {
 		return MetaJson.Marshal(i.Val)
@@ -339,9 +412,79 @@ func (i Int64) MarshalJSON() ([]byte, error) {
 	return nullString, nil
 }
 
+// UnmarshalJSON implements json.Unmarshaler using the default options. null sets Null, so values round trip through MarshalJSON.
+func (i *Int64) UnmarshalJSON(data []byte) error {
+	*i = Int64{}
+	if isJSONNull(data) {
+		i.Present = true
+		i.Null = true
+		return nil
+	}
+	opts := i.ParseOptions("")
+	return unmarshalJSONValue(i, data, opts)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using the default options.
+func (i *Int64) UnmarshalText(text []byte) error {
+	*i = Int64{}
+	opts := i.ParseOptions("")
+	if err := i.FormValue(string(text), opts); err != nil {
+		return err
+	}
+	return nil
+}
+
+// String implements flag.Value.
+func (i *Int64) String() string {
+	if !i.Present || i.Null {
+		return ""
+	}
+	return strconv.FormatInt(i.Val, 10)
+}
+
+// Set implements flag.Value using the default options.
+func (i *Int64) Set(value string) error {
+	return i.UnmarshalText([]byte(value))
+}
+
 func (i Uint64) MarshalJSON() ([]byte, error) {
 	if i.Present && !i.Null {
 		return MetaJson.Marshal(i.Val)
 	}
 	return nullString, nil
 }
+
+// UnmarshalJSON implements json.Unmarshaler using the default options. null sets Null, so values round trip through MarshalJSON.
+func (i *Uint64) UnmarshalJSON(data []byte) error {
+	*i = Uint64{}
+	if isJSONNull(data) {
+		i.Present = true
+		i.Null = true
+		return nil
+	}
+	opts := i.ParseOptions("")
+	return unmarshalJSONValue(i, data, opts)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using the default options.
+func (i *Uint64) UnmarshalText(text []byte) error {
+	*i = Uint64{}
+	opts := i.ParseOptions("")
+	if err := i.FormValue(string(text), opts); err != nil {
+		return err
+	}
+	return nil
+}
+
+// String implements flag.Value.
+func (i *Uint64) String() string {
+	if !i.Present || i.Null {
+		return ""
+	}
+	return strconv.FormatUint(i.Val, 10)
+}
+
+// Set implements flag.Value using the default options.
+func (i *Uint64) Set(value string) error {
+	return i.UnmarshalText([]byte(value))
+}
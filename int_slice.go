@@ -2,6 +2,7 @@ package meta
 
 import (
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -35,6 +36,8 @@ func (n *Int64Slice) JSONValue(path string, i interface{}, options interface{})
 		return ErrBlank
 	}
 
+	i = options.(*IntSliceOptions).SliceOptions.formValue(i)
+
 	var errorsInSlice ErrorSlice
 	switch value := i.(type) {
 	case string:
@@ -116,3 +119,44 @@ func (s Int64Slice) MarshalJSON() ([]byte, error) {
 	}
 	return nullString, nil
 }
+
+// UnmarshalJSON implements json.Unmarshaler using the default options. null and [] leave Val empty.
+func (s *Int64Slice) UnmarshalJSON(data []byte) error {
+	*s = Int64Slice{}
+	if isJSONNull(data) {
+		return nil
+	}
+	err := unmarshalJSONValue(s, data, s.ParseOptions(""))
+	if err == ErrBlank {
+		return nil
+	}
+	return err
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler for comma separated values using the default options.
+func (s *Int64Slice) UnmarshalText(text []byte) error {
+	*s = Int64Slice{}
+	if err := s.FormValue(string(text), s.ParseOptions("")); err != nil && err != ErrBlank {
+		return err
+	}
+	return nil
+}
+
+// String implements flag.Value.
+func (s *Int64Slice) String() string {
+	strs := make([]string, len(s.Val))
+	for i, n := range s.Val {
+		strs[i] = strconv.FormatInt(n, 10)
+	}
+	return strings.Join(strs, ",")
+}
+
+// Set implements flag.Value. Each value is comma separated and added to Val, so the flag can be repeated: -ids=1,2 -ids=3.
+func (s *Int64Slice) Set(value string) error {
+	var more Int64Slice
+	if err := more.UnmarshalText([]byte(value)); err != nil {
+		return err
+	}
+	s.Val = append(s.Val, more.Val...)
+	return nil
+}
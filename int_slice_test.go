@@ -119,3 +119,23 @@ func TestIntSliceLength(t *testing.T) {
 	assertEqual(t, e, ErrorHash{"a": ErrMaxLength})
 	assertEqual(t, len(inputs.A.Val), 0)
 }
+
+func TestIntSliceRepeatedKeys(t *testing.T) {
+	var inputs withIntSlice
+	e := withIntSliceDecoder.DecodeValues(&inputs, url.Values{"a": {"1,2", "3"}})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, []int64{1, 2, 3})
+
+	comma := NewDecoderWithOptions(&withIntSlice{}, DecoderOptions{FormSlices: FormSlicesComma})
+	e = comma.DecodeValues(&inputs, url.Values{"a": {"1,2", "3"}})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, []int64{1, 2})
+
+	repeat := NewDecoderWithOptions(&withIntSlice{}, DecoderOptions{FormSlices: FormSlicesRepeat})
+	e = repeat.DecodeValues(&inputs, url.Values{"a": {"4", "5"}})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, []int64{4, 5})
+
+	e = repeat.DecodeValues(&inputs, url.Values{"a": {"1,2", "3"}})
+	assertEqual(t, e, ErrorHash{"a": ErrorSlice{ErrInt, nil}})
+}
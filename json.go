@@ -34,3 +34,19 @@ func (j *defaultJsonLoader) UnmarshalUsingNumber(data []byte, v interface{}) err
 	dec.UseNumber()
 	return dec.Decode(v)
 }
+
+// unmarshalJSONValue decodes data and passes the result to v.JSONValue, the same way a Decoder would for a field with the given options.
+func unmarshalJSONValue(v Valuer, data []byte, options interface{}) error {
+	var i interface{}
+	if err := MetaJson.UnmarshalUsingNumber(data, &i); err != nil {
+		return err
+	}
+	if err := v.JSONValue("", i, options); err != nil {
+		return err
+	}
+	return nil
+}
+
+func isJSONNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), nullString)
+}
@@ -0,0 +1,210 @@
+package meta
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// inputStats is the shape of an input, measured before decoding so that hostile input can be rejected cheaply.
+type inputStats struct {
+	depth    int // deepest nesting of objects and arrays
+	keys     int // total number of object keys
+	elements int // largest number of elements in a single array
+}
+
+// checkLimits returns the error for the first limit in d.Options that stats exceeds, or nil.
+func (d *Decoder) checkLimits(stats inputStats) Errorable {
+	if d.Options.MaxDepth > 0 && stats.depth > d.Options.MaxDepth {
+		return ErrMaxDepth
+	}
+	if d.Options.MaxKeys > 0 && stats.keys > d.Options.MaxKeys {
+		return ErrMaxKeys
+	}
+	if d.Options.MaxElements > 0 && stats.elements > d.Options.MaxElements {
+		return ErrMaxElements
+	}
+	return nil
+}
+
+// checkInput checks a JSON body and form values against the limits in d.Options.
+func (d *Decoder) checkInput(b []byte, values ...url.Values) Errorable {
+	if d.Options.MaxBodyBytes > 0 && int64(len(b)) > d.Options.MaxBodyBytes {
+		return ErrBodyTooLarge
+	}
+	if !d.hasLimits() {
+		return nil
+	}
+	stats := jsonStats(b)
+	for _, v := range values {
+		stats.merge(formStats(v))
+	}
+	return d.checkLimits(stats)
+}
+
+// treeSource is a source that reads a parsed tree of maps, slices and values, eg a YAML document.
+type treeSource interface {
+	// tree returns the parsed tree, or false if the input couldn't be parsed.
+	tree() (interface{}, bool)
+}
+
+// checkTree checks a parsed input, eg a YAML or XML document, against the limits in d.Options.
+func (d *Decoder) checkTree(src Source) Errorable {
+	if !d.hasLimits() {
+		return nil
+	}
+	if tree, ok := src.(treeSource); ok {
+		if value, ok := tree.tree(); ok {
+			return d.checkLimits(valueStats(value))
+		}
+	}
+	return nil
+}
+
+func (d *Decoder) hasLimits() bool {
+	return d.Options.MaxDepth > 0 || d.Options.MaxKeys > 0 || d.Options.MaxElements > 0
+}
+
+func (s *inputStats) merge(other inputStats) {
+	if other.depth > s.depth {
+		s.depth = other.depth
+	}
+	if other.elements > s.elements {
+		s.elements = other.elements
+	}
+	s.keys += other.keys
+}
+
+// jsonStats measures b in a single pass without unmarshalling it. Malformed JSON is measured as best it can be; it's reported when it's decoded.
+func jsonStats(b []byte) inputStats {
+	type frame struct {
+		array    bool
+		commas   int
+		nonEmpty bool
+	}
+	var stats inputStats
+	var stack []frame
+	inString, escaped := false, false
+
+	for _, c := range b {
+		if inString {
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case '{', '[':
+			if len(stack) > 0 {
+				stack[len(stack)-1].nonEmpty = true
+			}
+			stack = append(stack, frame{array: c == '['})
+			if len(stack) > stats.depth {
+				stats.depth = len(stack)
+			}
+			continue
+		case '}', ']':
+			if len(stack) > 0 {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if top.array && top.nonEmpty && top.commas+1 > stats.elements {
+					stats.elements = top.commas + 1
+				}
+			}
+			continue
+		case ':':
+			stats.keys += 1
+		case ',':
+			if len(stack) > 0 {
+				stack[len(stack)-1].commas += 1
+			}
+		case '"':
+			inString = true
+		}
+		if len(stack) > 0 {
+			stack[len(stack)-1].nonEmpty = true
+		}
+	}
+
+	return stats
+}
+
+// valueStats measures a tree of map[string]interface{}, []interface{} and values, like the one DecodeMap takes.
+func valueStats(v interface{}) inputStats {
+	var stats inputStats
+	switch value := v.(type) {
+	case map[string]interface{}:
+		stats.keys = len(value)
+		numeric := 0
+		for k, el := range value {
+			if _, err := strconv.Atoi(k); err == nil {
+				numeric += 1
+			}
+			stats.merge(valueStats(el))
+		}
+		// In form input, slices are maps with numeric keys.
+		if numeric > stats.elements {
+			stats.elements = numeric
+		}
+		stats.depth += 1
+	case []interface{}:
+		for _, el := range value {
+			stats.merge(valueStats(el))
+		}
+		if len(value) > stats.elements {
+			stats.elements = len(value)
+		}
+		stats.depth += 1
+	case []string:
+		if len(value) > stats.elements {
+			stats.elements = len(value)
+		}
+	}
+	return stats
+}
+
+func formStats(values url.Values) inputStats {
+	if len(values) == 0 {
+		return inputStats{}
+	}
+	return valueStats(formValueTree(values))
+}
+
+// decodeState is shared by a decode and all of its nested decodes.
+type decodeState struct {
+	maxErrors int
+	errors    int
+}
+
+func (st *decodeState) tooManyErrors() bool {
+	return st.maxErrors > 0 && st.errors >= st.maxErrors
+}
+
+// addError adds an error like addError and counts it toward MaxErrors.
+// Nested ErrorHashes have already been counted by the nested decode, so only their leaves count.
+func (st *decodeState) addError(errs ErrorHash, key string, value Errorable) ErrorHash {
+	st.errors += countErrors(value)
+	return addError(errs, key, value)
+}
+
+func countErrors(e Errorable) int {
+	switch err := e.(type) {
+	case ErrorHash:
+		return 0
+	case ErrorSlice:
+		n := 0
+		for _, el := range err {
+			if el != nil {
+				n += countErrors(el)
+			}
+		}
+		return n
+	}
+	return 1
+}
@@ -0,0 +1,83 @@
+package meta
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMaxBodyBytes(t *testing.T) {
+	d := NewDecoderWithOptions(&withString{}, DecoderOptions{MaxBodyBytes: 10})
+
+	var inputs withString
+	e := d.DecodeJSON(&inputs, []byte(`{"a":"ok"}`))
+	assertEqual(t, e, ErrorHash(nil))
+
+	e = d.DecodeJSON(&inputs, []byte(`{"a":"too long"}`))
+	assertEqual(t, e, ErrorHash{"error": ErrBodyTooLarge})
+
+	_, e = d.NewDecoded(nil, bytes.NewReader([]byte(`{"a":"ok"}`)))
+	assertEqual(t, e, ErrorHash(nil))
+
+	_, e = d.NewDecoded(nil, strings.NewReader(`{"a":"`+strings.Repeat("x", 1<<20)+`"}`))
+	assertEqual(t, e, ErrorHash{"error": ErrBodyTooLarge})
+}
+
+func TestMaxDepth(t *testing.T) {
+	d := NewDecoderWithOptions(&nested{}, DecoderOptions{MaxDepth: 2})
+
+	var inputs nested
+	e := d.DecodeJSON(&inputs, []byte(`{"a":"1","c":{"d":"[{not nested}]"}}`))
+	assertEqual(t, e, ErrorHash(nil))
+
+	e = d.DecodeJSON(&inputs, []byte(`{"a":"1","c":{"d":"2"},"x":[[[[1]]]]}`))
+	assertEqual(t, e, ErrorHash{"error": ErrMaxDepth})
+
+	e = d.DecodeValues(&inputs, url.Values{"a": {"1"}, "c.d": {"2"}, "x.y.z": {"3"}})
+	assertEqual(t, e, ErrorHash{"error": ErrMaxDepth})
+
+	e = d.DecodeMap(&inputs, map[string]interface{}{"a": "1", "x": []interface{}{[]interface{}{1}}})
+	assertEqual(t, e, ErrorHash{"error": ErrMaxDepth})
+}
+
+func TestMaxElements(t *testing.T) {
+	d := NewDecoderWithOptions(&withSliceString{}, DecoderOptions{MaxElements: 2})
+
+	var inputs withSliceString
+	e := d.DecodeJSON(&inputs, []byte(`{"a":["1","2"],"b":[]}`))
+	assertEqual(t, e, ErrorHash(nil))
+
+	e = d.DecodeJSON(&inputs, []byte(`{"a":["1","2","3"]}`))
+	assertEqual(t, e, ErrorHash{"error": ErrMaxElements})
+
+	e = d.DecodeValues(&inputs, url.Values{"a.0": {"1"}, "a.1": {"2"}, "a.2": {"3"}})
+	assertEqual(t, e, ErrorHash{"error": ErrMaxElements})
+}
+
+func TestMaxKeys(t *testing.T) {
+	d := NewDecoderWithOptions(&withString{}, DecoderOptions{MaxKeys: 2})
+
+	var inputs withString
+	e := d.DecodeJSON(&inputs, []byte(`{"a":"1","b":{"c":"d:e"}}`))
+	assertEqual(t, e, ErrorHash{"error": ErrMaxKeys})
+
+	e = d.DecodeJSON(&inputs, []byte(`{"a":"1","b":"c:d"}`))
+	assertEqual(t, e, ErrorHash(nil))
+}
+
+func TestMaxErrors(t *testing.T) {
+	var inputs struct {
+		A Int64
+		B Int64
+		C []Int64
+		D Int64
+	}
+	d := NewDecoderWithOptions(&inputs, DecoderOptions{MaxErrors: 2})
+
+	e := d.DecodeJSON(&inputs, []byte(`{"a":"x","b":"1","c":["x","x"],"d":"x"}`))
+	assertEqual(t, e, ErrorHash{"a": ErrInt, "c": ErrorSlice{ErrInt, ErrInt}, "error": ErrTooManyErrors})
+
+	e = d.DecodeJSON(&inputs, []byte(`{"a":"x"}`))
+	assertEqual(t, e, ErrorHash{"a": ErrInt})
+}
@@ -6,7 +6,10 @@ import (
 	"io/ioutil"
 	"net/url"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 type Valuer interface {
@@ -30,6 +33,8 @@ const (
 	categorySliceOfValues
 	categorySliceOfStructs
 	categoryAllFieldsMap
+	categoryRestFieldsMap
+	categoryUnsupported
 )
 
 var nullString = []byte("null")
@@ -39,6 +44,48 @@ type SliceOptions struct {
 	MinLength        int
 	MaxLengthPresent bool
 	MaxLength        int
+	FormSlices       FormSlices // set from DecoderOptions.FormSlices
+}
+
+// SparseIndices is how slices with gaps in their indices are decoded, eg a form with items.0 and items.2.
+type SparseIndices int
+
+const (
+	SparseIndicesStop    SparseIndices = iota // stop at the first gap, dropping items.2
+	SparseIndicesCompact                      // sort the indices and close the gaps, so items.2 is the second element
+	SparseIndicesError                        // report ErrSparseIndex for the slice
+)
+
+// FormSlices is how Int64Slice and StringSlice read form values.
+type FormSlices int
+
+const (
+	FormSlicesCommaOrRepeat FormSlices = iota // ids=1,2 or ids=1&ids=2, or both: ids=1,2&ids=3
+	FormSlicesComma                           // ids=1,2. If the key is repeated, the first value is used
+	FormSlicesRepeat                          // ids=1&ids=2. Values aren't split, so tags=a,b is the single tag "a,b"
+)
+
+// formValue rewrites a form value, the []string that sourceValue reads, for FormSlices.
+// It returns a string to split on commas, or a []interface{} of elements. Anything else, eg a JSON string, is returned as is.
+func (opts *SliceOptions) formValue(i interface{}) interface{} {
+	switch value := i.(type) {
+	case []string:
+		switch opts.FormSlices {
+		case FormSlicesComma:
+			if len(value) > 0 {
+				return value[0]
+			}
+		case FormSlicesRepeat:
+			values := make([]interface{}, len(value))
+			for j, v := range value {
+				values[j] = v
+			}
+			return values
+		default:
+			return strings.Join(value, ",")
+		}
+	}
+	return i
 }
 
 func ParseSliceOptions(tag reflect.StructTag) *SliceOptions {
@@ -74,6 +121,7 @@ type DecoderField struct {
 	Options         interface{}
 	needsAllocation bool // true if we need to reflect.New
 	Default         string
+	From            []string // If set, the field is only read from sources with these names, eg meta_from:"query" or its alias meta_in_location:"header"
 	Doc             string
 	DocPattern      string
 
@@ -108,30 +156,119 @@ type Decoder struct {
 
 type DecoderOptions struct {
 	TimeFormats []string
+	// DisallowUnknown reports ErrUnknownField for input keys that don't map to a field, unless the struct has a meta:"*" or meta:"*rest" field.
+	DisallowUnknown bool
+
+	// Limits for untrusted input. Zero means no limit.
+	MaxBodyBytes int64 // Largest JSON body; larger bodies are ErrBodyTooLarge
+	MaxDepth     int   // Deepest nesting of objects and arrays; ErrMaxDepth
+	MaxElements  int   // Most elements in any one slice; ErrMaxElements
+	MaxKeys      int   // Most keys in the whole input; ErrMaxKeys
+	MaxErrors    int   // Decoding stops once this many errors are found, and ErrTooManyErrors is added at "error"
+
+	// MaxMultipartMemory is passed to http.Request.ParseMultipartForm by DecodeRequest. It defaults to 32MB.
+	MaxMultipartMemory int64
+
+	// SourcePrecedence orders named sources (see NamedSource), eg []string{SourceQuery, SourceBody} to let query values win.
+	// Sources that aren't listed come after the ones that are. By default, Decode takes the body first.
+	SourcePrecedence []string
+	// SourceNames are the names of other sources that meta_from can name, eg a layer added with Config.AddLayer.
+	// The built-in sources, and the names in SourcePrecedence, can always be named. NewDecoderE reports any other name.
+	SourceNames []string
+	// DetectConflicts reports ErrConflict when two sources have different values for the same key.
+	DetectConflicts bool
+	// SparseIndices is what happens when slice indices have gaps, eg items.0 and items.2. By default the slice stops at the gap.
+	SparseIndices SparseIndices
+	// MaxIndex is the largest slice index allowed with SparseIndicesCompact or SparseIndicesError; larger ones are ErrMaxIndex.
+	// Zero means no limit.
+	MaxIndex int
+	// FormBrackets reads form keys in bracket notation too, eg user[address][city], tags[] and items[0][name].
+	// See NewBracketFormValueSource.
+	FormBrackets bool
+	// FormSlices is how Int64Slice and StringSlice read form values: comma separated, repeated keys, or either (the default).
+	FormSlices FormSlices
+	// EnforceDocPattern makes String and StringSlice fields without a meta_pattern tag validate against their doc_pattern.
+	EnforceDocPattern bool
 }
 
 func NewDecoderWithOptions(destStruct interface{}, options DecoderOptions) *Decoder {
+	b := &decoderBuilder{options: options}
+	return b.build(destStruct, "")
+}
+
+// NewDecoderE builds a Decoder like NewDecoderWithOptions, but instead of panicking on a bad struct definition
+// it checks every field, tag and option up front and returns all of the problems it found as DefinitionErrors.
+func NewDecoderE(destStruct interface{}, options DecoderOptions) (*Decoder, error) {
+	b := &decoderBuilder{options: options, collect: true}
+	decoder := b.build(destStruct, "")
+	if len(b.errs) > 0 {
+		return nil, b.errs
+	}
+	return decoder, nil
+}
+
+// MustNewDecoder is like NewDecoderE but panics if the struct definition has any problems.
+// It's meant to be used when initializing package level decoders.
+func MustNewDecoder(destStruct interface{}, options DecoderOptions) *Decoder {
+	decoder, err := NewDecoderE(destStruct, options)
+	if err != nil {
+		panic(err.Error())
+	}
+	return decoder
+}
+
+// decoderBuilder builds a Decoder (and its nested decoders) from a struct definition.
+// If collect is set, problems are recorded in errs rather than panicking.
+type decoderBuilder struct {
+	options DecoderOptions
+	collect bool
+	errs    DefinitionErrors
+}
+
+func (b *decoderBuilder) addError(path string, format string, args ...interface{}) {
+	b.errs = append(b.errs, &DefinitionError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// try runs f, which may panic on a malformed tag. When collecting errors the panic is recorded against path and try returns false.
+func (b *decoderBuilder) try(path string, f func()) (ok bool) {
+	if !b.collect {
+		f()
+		return true
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			b.addError(path, "%v", r)
+			ok = false
+		}
+	}()
+	f()
+	return true
+}
+
+func (b *decoderBuilder) build(destStruct interface{}, path string) *Decoder {
 	destValue := reflect.ValueOf(destStruct)
-	indirectedDest := reflect.Indirect(destValue)
-	destType := indirectedDest.Type()
 
-	if destValue.Kind() == reflect.Ptr && indirectedDest.Kind() == reflect.Struct {
-		// we're good
+	var destType reflect.Type
+	if destValue.Kind() == reflect.Ptr && destValue.Type().Elem().Kind() == reflect.Struct {
+		destType = destValue.Type().Elem()
 	} else if destValue.Kind() == reflect.Struct {
 		destType = destValue.Type()
-		destValue = reflect.New(destType)
-		indirectedDest = reflect.Indirect(destValue)
 	} else {
-		panic(fmt.Sprintf("expect ptr to struct or struct, got %s", destValue.Kind()))
+		msg := fmt.Sprintf("expect ptr to struct or struct, got %s", destValue.Kind())
+		if !b.collect {
+			panic(msg)
+		}
+		b.addError(path, "%s", msg)
+		return nil
 	}
 
-	decoder := &Decoder{StructType: destType}
+	decoder := &Decoder{StructType: destType, Options: b.options}
+	names := make(map[string]bool)
 
-	fieldCount := indirectedDest.NumField()
+	fieldCount := destType.NumField()
 	for i := 0; i < fieldCount; i += 1 {
-		field := indirectedDest.Field(i)
 		fieldStruct := destType.Field(i) // type: StructField
-		fieldType := field.Type()
+		fieldType := fieldStruct.Type
 		fieldKind := fieldType.Kind()
 
 		var indirectedType reflect.Type
@@ -144,15 +281,6 @@ func NewDecoderWithOptions(destStruct interface{}, options DecoderOptions) *Deco
 			indirectedKind = fieldKind
 		}
 
-		var fieldInterface interface{} // This is going to be a pointer to a struct
-		var needsAllocation bool
-		if fieldKind == reflect.Struct {
-			fieldInterface = field.Addr().Interface()
-		} else if fieldKind == reflect.Ptr && indirectedKind == reflect.Struct {
-			fieldInterface = reflect.New(indirectedType).Interface()
-			needsAllocation = true
-		}
-
 		// Determine the key we're expecting in input
 		metaName := fieldStruct.Tag.Get("meta")
 		if metaName == "-" {
@@ -161,17 +289,47 @@ func NewDecoderWithOptions(destStruct interface{}, options DecoderOptions) *Deco
 			metaName = NameMapping(fieldStruct.Name)
 		}
 
+		fieldPath := joinPath(path, metaName)
+		if fieldStruct.Anonymous && indirectedKind == reflect.Struct {
+			fieldPath = path
+		}
+
+		// Unexported fields can't be set, so they're never decoded.
+		if fieldStruct.PkgPath != "" && !fieldStruct.Anonymous {
+			if b.collect {
+				b.addError(fieldPath, "field %s is unexported", fieldStruct.Name)
+			}
+			continue
+		}
+
+		if b.collect {
+			b.checkBoolTags(fieldPath, fieldStruct.Tag)
+		}
+
+		var fieldInterface interface{} // This is going to be a pointer to a struct
+		var needsAllocation bool
+		if fieldKind == reflect.Struct {
+			fieldInterface = reflect.New(fieldType).Interface()
+		} else if fieldKind == reflect.Ptr && indirectedKind == reflect.Struct {
+			fieldInterface = reflect.New(indirectedType).Interface()
+			needsAllocation = true
+		}
+
 		// Determine if it's required..
 		required := fieldStruct.Tag.Get("meta_required") == "true"
 
 		if fieldStruct.Anonymous && indirectedKind == reflect.Struct {
 			// It's an embedded struct:
-			embeddedDecoder := NewDecoderWithOptions(fieldInterface, options)
+			embeddedDecoder := b.build(fieldInterface, path)
+			if embeddedDecoder == nil {
+				continue
+			}
 
 			for _, embeddedDField := range embeddedDecoder.Fields {
 				idx := []int{i}
 				idx = append(idx, embeddedDField.fieldIndex...)
 				embeddedDField.fieldIndex = idx
+				b.checkDuplicate(names, path, embeddedDField.Name)
 				decoder.Fields = append(decoder.Fields, embeddedDField)
 			}
 		} else {
@@ -188,20 +346,50 @@ func NewDecoderWithOptions(destStruct interface{}, options DecoderOptions) *Deco
 
 			dfield.Doc = fieldStruct.Tag.Get("doc")
 			dfield.DocPattern = fieldStruct.Tag.Get("doc_pattern")
+			// meta_in_location is another name for meta_from, reading better for request locations, eg meta_in_location:"path".
+			from := fieldStruct.Tag.Get("meta_from")
+			if from == "" {
+				from = fieldStruct.Tag.Get("meta_in_location")
+			}
+			if from != "" {
+				for _, name := range strings.Split(from, ",") {
+					name = strings.TrimSpace(name)
+					if b.collect && !b.knownSource(name) {
+						b.addError(fieldPath, "unknown source %q in meta_from", name)
+					}
+					dfield.From = append(dfield.From, name)
+				}
+			}
 
 			// Determine what kind of field it is.
-			if metaName == "*" && indirectedKind == reflect.Map {
-				dfield.fieldCategory = categoryAllFieldsMap
+			if (metaName == "*" || metaName == "*rest") && indirectedKind == reflect.Map {
+				if metaName == "*" {
+					dfield.fieldCategory = categoryAllFieldsMap
+				} else {
+					dfield.fieldCategory = categoryRestFieldsMap
+				}
+				if b.collect && !reflectTypeValueMap.AssignableTo(fieldType) {
+					b.addError(fieldPath, "meta:%q field must be a map[string]interface{}, got %s", metaName, fieldType)
+					continue
+				}
 			} else if valuer, ok := fieldInterface.(Valuer); ok {
 				dfield.fieldCategory = categoryValuer
-				dfield.Options = getParsedOptions(valuer, fieldStruct, options)
+				if !b.try(fieldPath, func() { dfield.Options = getParsedOptions(valuer, fieldStruct, b.options) }) {
+					continue
+				}
 				if def := fieldStruct.Tag.Get("meta_default"); def != "" {
 					dfield.Default = def
+					if b.collect {
+						b.checkDefault(fieldPath, indirectedType, def, dfield.Options)
+					}
 				}
 				dfield.DiscardInvalid = fieldStruct.Tag.Get("meta_discard_invalid") == "true"
 			} else if indirectedKind == reflect.Struct {
 				dfield.fieldCategory = categoryStruct
-				dfield.StructDecoder = NewDecoderWithOptions(fieldInterface, options)
+				dfield.StructDecoder = b.build(fieldInterface, fieldPath)
+				if dfield.StructDecoder == nil {
+					continue
+				}
 			} else if indirectedKind == reflect.Slice {
 				var elemType, elemIndirectedType reflect.Type
 				var elemKind, elemIndirectedKind reflect.Kind
@@ -221,25 +409,45 @@ func NewDecoderWithOptions(destStruct interface{}, options DecoderOptions) *Deco
 				dfield.elemIndirectedType = elemIndirectedType
 				dfield.elemIndirectedKind = elemIndirectedKind
 
-				// Set slice validation options
-				dfield.SliceOptions = ParseSliceOptions(fieldStruct.Tag)
+				// Set slice validation options. A malformed slice tag is recorded, but the element type is still
+				// checked so that problems inside it are reported too.
+				sliceOK := b.try(fieldPath, func() { dfield.SliceOptions = ParseSliceOptions(fieldStruct.Tag) })
 
 				if reflect.PtrTo(elemIndirectedType).Implements(reflectTypeValuer) {
 					dfield.fieldCategory = categorySliceOfValues
 					valuer := reflect.New(elemIndirectedType).Interface().(Valuer) // Make a new object so we can use it to parse values.
-					dfield.Options = getParsedOptions(valuer, fieldStruct, options)
+					if !b.try(fieldPath, func() { dfield.Options = getParsedOptions(valuer, fieldStruct, b.options) }) {
+						continue
+					}
 				} else if elemIndirectedKind == reflect.Struct {
 					dfield.fieldCategory = categorySliceOfStructs
 					if elemIndirectedType == destType {
 						dfield.StructDecoder = decoder
 					} else {
-						dfield.StructDecoder = NewDecoderWithOptions(reflect.New(elemIndirectedType).Interface(), options)
+						dfield.StructDecoder = b.build(reflect.New(elemIndirectedType).Interface(), fieldPath+"[]")
+						if dfield.StructDecoder == nil {
+							continue
+						}
 					}
+				} else if b.collect {
+					b.addError(fieldPath, "unsupported slice element type %s", elemType)
+					continue
 				} else {
 					panic("unknown type of slice")
 				}
+				if !sliceOK {
+					continue
+				}
+			} else {
+				// Not something we know how to decode. Skip it rather than failing on the first request that sets it.
+				dfield.fieldCategory = categoryUnsupported
+				if b.collect {
+					b.addError(fieldPath, "unsupported field type %s", fieldType)
+					continue
+				}
 			}
 
+			b.checkDuplicate(names, path, metaName)
 			decoder.Fields = append(decoder.Fields, dfield)
 		}
 	}
@@ -247,6 +455,52 @@ func NewDecoderWithOptions(destStruct interface{}, options DecoderOptions) *Deco
 	return decoder
 }
 
+// knownSource reports whether name is a source that meta_from can name: a built-in one, or one in the decoder's options.
+func (b *decoderBuilder) knownSource(name string) bool {
+	return containsString(builtinSources, name) || containsString(b.options.SourceNames, name) ||
+		containsString(b.options.SourcePrecedence, name)
+}
+
+// checkBoolTags makes sure that boolean tags are either "true" or "false", so typos like meta_required:"ture" aren't silently ignored.
+func (b *decoderBuilder) checkBoolTags(path string, tag reflect.StructTag) {
+	for _, name := range boolTags {
+		if v, ok := tag.Lookup(name); ok && v != "true" && v != "false" {
+			b.addError(path, "%s must be \"true\" or \"false\", got %q", name, v)
+		}
+	}
+}
+
+func (b *decoderBuilder) checkDuplicate(names map[string]bool, path string, name string) {
+	if !b.collect {
+		return
+	}
+	if names[name] {
+		b.addError(joinPath(path, name), "duplicate meta name %q", name)
+	}
+	names[name] = true
+}
+
+// checkDefault runs meta_default through the field's own validation.
+func (b *decoderBuilder) checkDefault(path string, typ reflect.Type, def string, options interface{}) {
+	valuer := reflect.New(typ).Interface().(Valuer)
+	b.try(path, func() {
+		if err := valuer.JSONValue(path, def, options); err != nil {
+			b.addError(path, "meta_default %q is invalid: %s", def, err.Error())
+		}
+	})
+}
+
+var boolTags = []string{"meta_required", "meta_null", "meta_blank", "meta_strip", "meta_discard_blank", "meta_discard_invalid"}
+
+var reflectTypeValueMap = reflect.TypeOf(map[string]interface{}(nil))
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
 func getParsedOptions(valuer Valuer, fieldStruct reflect.StructField, options DecoderOptions) interface{} {
 	parsedOptions := valuer.ParseOptions(fieldStruct.Tag)
 	if timeOptions, ok := parsedOptions.(*TimeOptions); ok && len(options.TimeFormats) > 0 {
@@ -254,6 +508,26 @@ func getParsedOptions(valuer Valuer, fieldStruct reflect.StructField, options De
 		parsedOptions = timeOptions
 	}
 
+	switch opts := parsedOptions.(type) {
+	case *IntSliceOptions:
+		opts.SliceOptions.FormSlices = options.FormSlices
+	case *StringSliceOptions:
+		opts.SliceOptions.FormSlices = options.FormSlices
+	}
+
+	if options.EnforceDocPattern {
+		var stringOptions *StringOptions
+		switch opts := parsedOptions.(type) {
+		case *StringOptions:
+			stringOptions = opts
+		case *StringSliceOptions:
+			stringOptions = opts.StringOptions
+		}
+		if docPattern := fieldStruct.Tag.Get("doc_pattern"); stringOptions != nil && stringOptions.Pattern == nil && docPattern != "" {
+			stringOptions.Pattern = regexp.MustCompile(docPattern)
+		}
+	}
+
 	return parsedOptions
 }
 
@@ -261,23 +535,75 @@ func NewDecoder(destStruct interface{}) *Decoder {
 	return NewDecoderWithOptions(destStruct, DecoderOptions{})
 }
 
+// Decode decodes a JSON body and form values. They're named SourceBody and SourceQuery, for SourcePrecedence and meta_from.
 func (d *Decoder) Decode(dest interface{}, values url.Values, b []byte) ErrorHash {
-	return d.decode(reflect.ValueOf(dest), newMergedSource(newJSONSource(b), newFormValueSource(values)))
+	values = d.formValues(values)
+	if err := d.checkInput(b, values); err != nil {
+		return ErrorHash{"error": err}
+	}
+	return d.decodeSource(reflect.ValueOf(dest), NewMergedSource(NewNamedSource(SourceBody, NewJSONSource(b)), NewNamedSource(SourceQuery, NewFormValueSource(values))))
 }
 
 func (d *Decoder) DecodeJSON(dest interface{}, b []byte) ErrorHash {
 	return d.Decode(dest, nil, b)
 }
 
+// formValues rewrites bracket keys as dotted keys if FormBrackets is set.
+func (d *Decoder) formValues(values url.Values) url.Values {
+	if d.Options.FormBrackets && len(values) > 0 {
+		return bracketValues(values)
+	}
+	return values
+}
+
 func (d *Decoder) DecodeValues(dest interface{}, values url.Values) ErrorHash {
 	return d.Decode(dest, values, nil)
 }
 
 func (d *Decoder) DecodeMap(dest interface{}, m map[string]interface{}) ErrorHash {
-	return d.decode(reflect.ValueOf(dest), newMapSource(m))
+	if d.hasLimits() {
+		if err := d.checkLimits(valueStats(m)); err != nil {
+			return ErrorHash{"error": err}
+		}
+	}
+	return d.decodeSource(reflect.ValueOf(dest), NewMapSource(m))
+}
+
+// DecodeSource decodes from one or more sources. When there's more than one, values are taken from the first source that has them.
+func (d *Decoder) DecodeSource(dest interface{}, src ...Source) ErrorHash {
+	if len(src) == 1 {
+		return d.decodeSource(reflect.ValueOf(dest), src[0])
+	}
+	return d.decodeSource(reflect.ValueOf(dest), NewMergedSource(src...))
+}
+
+// decodeBody decodes a body in a format that's parsed into a tree, eg YAML, with newSource. Like DecodeJSON, the body
+// is limited to MaxBodyBytes, checked against the other limits in d.Options and named SourceBody.
+func (d *Decoder) decodeBody(dest interface{}, b []byte, newSource func([]byte) Source) ErrorHash {
+	if d.Options.MaxBodyBytes > 0 && int64(len(b)) > d.Options.MaxBodyBytes {
+		return ErrorHash{"error": ErrBodyTooLarge}
+	}
+	src := newSource(b)
+	if err := d.checkTree(src); err != nil {
+		return ErrorHash{"error": err}
+	}
+	return d.decodeSource(reflect.ValueOf(dest), NewNamedSource(SourceBody, src))
 }
 
-func (d *Decoder) decode(destValue reflect.Value, src source) ErrorHash {
+// decodeSource decodes src into destValue, stopping early once MaxErrors errors have been found.
+func (d *Decoder) decodeSource(destValue reflect.Value, src Source) ErrorHash {
+	if len(d.Options.SourcePrecedence) > 0 {
+		src = orderSources(src, d.Options.SourcePrecedence)
+	}
+	st := &decodeState{maxErrors: d.Options.MaxErrors}
+	errs := d.decode(destValue, src, st)
+	if st.tooManyErrors() {
+		errs = addError(errs, "error", ErrTooManyErrors)
+	}
+	return errs
+}
+
+func (d *Decoder) decode(destValue reflect.Value, src Source, st *decodeState) ErrorHash {
 	var errs ErrorHash
 
 	indirectedDest := reflect.Indirect(destValue) // This should be the value of the struct
@@ -291,23 +617,39 @@ func (d *Decoder) decode(destValue reflect.Value, src source) ErrorHash {
 	}
 
 	for _, dfield := range d.Fields {
+		if st.tooManyErrors() {
+			return errs
+		}
+
 		fieldValue := indirectedDest.FieldByIndex(dfield.fieldIndex)
 
+		var fieldSrc Source
+		if len(dfield.From) > 0 {
+			fieldSrc = filterSources(src, dfield.From)
+		} else {
+			fieldSrc = defaultSources(src)
+		}
+
 		metaName := dfield.Name
 
 		switch dfield.fieldCategory {
 		case categoryValuer:
-			nestedValues := src.Get(metaName)
+			nestedValues := fieldSrc.Get(metaName)
 			if nestedValues.Malformed() {
 				return ErrorHash{
 					"error": ErrMalformed,
 				}
 			}
 
+			if d.Options.DetectConflicts && conflicting(nestedValues, dfield.indirectedType, dfield.Options) {
+				errs = st.addError(errs, metaName, ErrConflict)
+				continue
+			}
+
 			ok := !nestedValues.Empty()
 			var val interface{}
 			if ok {
-				nestedValues.Value(&val)
+				val, _ = sourceValue(nestedValues, dfield.Options)
 			} else if dfield.Default != "" {
 				val = dfield.Default
 				ok = true
@@ -321,16 +663,16 @@ func (d *Decoder) decode(destValue reflect.Value, src source) ErrorHash {
 				}
 				err = valuerValue.Interface().(Valuer).JSONValue(nestedValues.Path(), val, dfield.Options)
 				if err != nil && !dfield.DiscardInvalid {
-					errs = addError(errs, metaName, err)
+					errs = st.addError(errs, metaName, err)
 				}
 			} else if dfield.Required {
-				errs = addError(errs, metaName, ErrRequired)
+				errs = st.addError(errs, metaName, ErrRequired)
 			}
 		case categoryStruct:
 			// Construct nestedValues
 			// if the struct name is like FooBar,
 			// {foo_bar.x=1, foo_bar.y=2} -> {x=1, y=2}
-			nestedValues := src.Get(metaName)
+			nestedValues := fieldSrc.Get(metaName)
 			if nestedValues.Malformed() {
 				return ErrorHash{
 					"error": ErrMalformed,
@@ -341,21 +683,27 @@ func (d *Decoder) decode(destValue reflect.Value, src source) ErrorHash {
 				var err ErrorHash
 				if dfield.needsAllocation {
 					fieldValue.Set(reflect.New(dfield.indirectedType))
-					err = dfield.StructDecoder.decode(fieldValue, nestedValues)
+					err = dfield.StructDecoder.decode(fieldValue, nestedValues, st)
 				} else {
-					err = dfield.StructDecoder.decode(fieldValue.Addr(), nestedValues)
+					err = dfield.StructDecoder.decode(fieldValue.Addr(), nestedValues, st)
 				}
 				if err != nil {
-					errs = addError(errs, metaName, err)
+					errs = st.addError(errs, metaName, err)
 				}
 			} else if dfield.Required {
-				errs = addError(errs, metaName, ErrRequired)
+				errs = st.addError(errs, metaName, ErrRequired)
 			}
 		case categorySliceOfValues:
 			sliceValue := fieldValue
 			var errorsInSlice ErrorSlice
 
-			sliceSrc := src.Get(metaName)
+			sliceSrc, err := d.sliceSource(fieldSrc.Get(metaName))
+			if err == ErrMaxIndex {
+				return ErrorHash{"error": err}
+			} else if err != nil {
+				errs = st.addError(errs, metaName, err)
+				break
+			}
 			for i := 0; true; i += 1 {
 				nestedValues := sliceSrc.Get(fmt.Sprint(i)) // foo_bar.0, foo_bar.1, ...
 				if nestedValues.Malformed() {
@@ -366,6 +714,13 @@ func (d *Decoder) decode(destValue reflect.Value, src source) ErrorHash {
 				if nestedValues.Empty() {
 					break
 				}
+				if d.Options.MaxElements > 0 && i >= d.Options.MaxElements {
+					return ErrorHash{"error": ErrMaxElements}
+				}
+				if d.Options.DetectConflicts && conflicting(nestedValues, dfield.elemIndirectedType, dfield.Options) {
+					errorsInSlice = append(errorsInSlice, ErrConflict)
+					continue
+				}
 				var val interface{}
 				nestedValues.Value(&val)
 				elPtrValue := reflect.New(dfield.elemIndirectedType)
@@ -385,14 +740,20 @@ func (d *Decoder) decode(destValue reflect.Value, src source) ErrorHash {
 
 			fieldValue.Set(sliceValue)
 			if errorsInSlice.Len() > 0 {
-				errs = addError(errs, metaName, errorsInSlice)
+				errs = st.addError(errs, metaName, errorsInSlice)
 			}
 		case categorySliceOfStructs:
 			sliceValue := fieldValue
 			var errorsInSlice ErrorSlice
 
 			var i int
-			sliceSrc := src.Get(metaName)
+			sliceSrc, err := d.sliceSource(fieldSrc.Get(metaName))
+			if err == ErrMaxIndex {
+				return ErrorHash{"error": err}
+			} else if err != nil {
+				errs = st.addError(errs, metaName, err)
+				break
+			}
 			for ; true; i += 1 {
 				nestedValues := sliceSrc.Get(fmt.Sprint(i)) // foo_bar.0, foo_bar.1, ...
 				if nestedValues.Malformed() {
@@ -404,9 +765,12 @@ func (d *Decoder) decode(destValue reflect.Value, src source) ErrorHash {
 				if nestedValues.Empty() {
 					break
 				}
+				if d.Options.MaxElements > 0 && i >= d.Options.MaxElements {
+					return ErrorHash{"error": ErrMaxElements}
+				}
 				elPtrValue := reflect.New(dfield.elemIndirectedType)
 
-				if err := dfield.StructDecoder.decode(elPtrValue, nestedValues); err != nil {
+				if err := dfield.StructDecoder.decode(elPtrValue, nestedValues, st); err != nil {
 					errorsInSlice = append(errorsInSlice, err)
 				} else {
 					errorsInSlice = append(errorsInSlice, nil)
@@ -421,23 +785,67 @@ func (d *Decoder) decode(destValue reflect.Value, src source) ErrorHash {
 
 			// Validate the length of the slice
 			if dfield.MinLengthPresent && dfield.MinLength > i {
-				errs = addError(errs, metaName, ErrMinLength)
+				errs = st.addError(errs, metaName, ErrMinLength)
 			} else if dfield.MaxLengthPresent && dfield.MaxLength < i {
-				errs = addError(errs, metaName, ErrMaxLength)
+				errs = st.addError(errs, metaName, ErrMaxLength)
 			} else {
 				fieldValue.Set(sliceValue)
 				if errorsInSlice.Len() > 0 {
-					errs = addError(errs, metaName, errorsInSlice)
+					errs = st.addError(errs, metaName, errorsInSlice)
 				}
 			}
 		case categoryAllFieldsMap:
 			fieldValue.Set(reflect.ValueOf(src.ValueMap()))
+		case categoryRestFieldsMap:
+			if rest := d.unknownValues(src); len(rest) > 0 {
+				fieldValue.Set(reflect.ValueOf(rest))
+			}
+		}
+	}
+
+	if d.Options.DisallowUnknown && !d.hasCatchAll() {
+		for key := range d.unknownValues(src) {
+			errs = st.addError(errs, key, ErrUnknownField)
 		}
 	}
 
 	return errs
 }
 
+// unknownValues returns the values in src whose keys don't map to any field.
+func (d *Decoder) unknownValues(src Source) map[string]interface{} {
+	var unknown map[string]interface{}
+	for key, value := range src.ValueMap() {
+		if d.field(key) == nil {
+			if unknown == nil {
+				unknown = make(map[string]interface{})
+			}
+			unknown[key] = value
+		}
+	}
+	return unknown
+}
+
+// field returns the field that decodes the input key name, or nil if there isn't one. Catch-all fields don't match any name.
+func (d *Decoder) field(name string) *DecoderField {
+	for i := range d.Fields {
+		dfield := &d.Fields[i]
+		if dfield.Name == name && dfield.fieldCategory != categoryAllFieldsMap && dfield.fieldCategory != categoryRestFieldsMap {
+			return dfield
+		}
+	}
+	return nil
+}
+
+func (d *Decoder) hasCatchAll() bool {
+	for _, dfield := range d.Fields {
+		if dfield.fieldCategory == categoryAllFieldsMap || dfield.fieldCategory == categoryRestFieldsMap {
+			return true
+		}
+	}
+	return false
+}
+
 // Given the decoder, makes a new struct and tries to map the values onto it. If it succeeds, returns that struct. Otherwise, returns the errors.
 func (d *Decoder) NewDecodedValues(values url.Values) (interface{}, ErrorHash) {
 	return d.NewDecoded(values, nil)
@@ -453,6 +861,10 @@ func (d *Decoder) NewDecoded(values url.Values, r io.Reader) (interface{}, Error
 	var b []byte
 	if r != nil {
 		var err error
+		if d.Options.MaxBodyBytes > 0 {
+			// read one byte past the limit so we can tell if there was more
+			r = io.LimitReader(r, d.Options.MaxBodyBytes+1)
+		}
 		b, err = ioutil.ReadAll(r)
 		if err != nil {
 			// error hash is used to make it compatible with DecodeValues.
@@ -474,3 +886,33 @@ func addError(errs ErrorHash, key string, value Errorable) ErrorHash {
 	errs[key] = value
 	return errs
 }
+
+// sliceSource applies SparseIndices to the source of a slice. With SparseIndicesCompact, the returned source has
+// the slice's indices in order without gaps. Only sources with numeric keys, like a form's items.0 and items.2, can have gaps.
+func (d *Decoder) sliceSource(src Source) (Source, Errorable) {
+	if d.Options.SparseIndices == SparseIndicesStop {
+		return src, nil
+	}
+
+	var indices []int
+	for key := range src.ValueMap() {
+		if index, err := strconv.Atoi(key); err == nil && index >= 0 && strconv.Itoa(index) == key {
+			indices = append(indices, index)
+		}
+	}
+	if len(indices) == 0 {
+		return src, nil
+	}
+	sort.Ints(indices)
+
+	if d.Options.MaxIndex > 0 && indices[len(indices)-1] > d.Options.MaxIndex {
+		return nil, ErrMaxIndex
+	}
+	if indices[len(indices)-1] == len(indices)-1 {
+		return src, nil
+	}
+	if d.Options.SparseIndices == SparseIndicesError {
+		return nil, ErrSparseIndex
+	}
+	return &compactSource{Source: src, indices: indices}, nil
+}
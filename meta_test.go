@@ -1,11 +1,14 @@
 package meta
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
+	"math"
 	"net/url"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func assert(t *testing.T, this bool) {
@@ -144,6 +147,62 @@ func TestValuers(t *testing.T) {
 	assertEqual(t, int64(1), v)
 }
 
+// Ensure that all our types implement sql.Scanner so rows can be read back into the same structs.
+func TestScanners(t *testing.T) {
+	var scanner sql.Scanner
+
+	var s String
+	scanner = &s
+	assert(t, scanner.Scan([]byte("abc")) == nil)
+	assertEqual(t, s, String{"abc", Nullity{false}, Presence{true}, ""})
+	assert(t, scanner.Scan(nil) == nil)
+	assertEqual(t, s, String{"", Nullity{true}, Presence{true}, ""})
+
+	var i64 Int64
+	assert(t, i64.Scan(int64(-3)) == nil)
+	assertEqual(t, i64, NewInt64(-3))
+	assert(t, i64.Scan("x") != nil)
+	assertEqual(t, i64.Present, false)
+
+	var ui64 Uint64
+	assert(t, ui64.Scan(int64(3)) == nil)
+	assertEqual(t, ui64, NewUint64(3))
+	assert(t, ui64.Scan(int64(-3)) != nil)
+	assert(t, ui64.Scan(nil) == nil)
+	assertEqual(t, ui64.Null, true)
+
+	// Uint64 values above MaxInt64 round trip through their decimal string
+	big := NewUint64(math.MaxUint64)
+	v, err := big.Value()
+	assert(t, err == nil)
+	assertEqual(t, v, "18446744073709551615")
+	ui64 = Uint64{}
+	assert(t, ui64.Scan(v) == nil)
+	assertEqual(t, ui64, big)
+
+	var f Float64
+	assert(t, f.Scan(float64(1.5)) == nil)
+	assertEqual(t, f, NewFloat64(1.5))
+	assert(t, f.Scan([]byte("2.5")) == nil)
+	assertEqual(t, f, NewFloat64(2.5))
+
+	var b Bool
+	assert(t, b.Scan(int64(1)) == nil)
+	assertEqual(t, b, NewBool(true))
+	assert(t, b.Scan("false") == nil)
+	assertEqual(t, b, NewBool(false))
+	assert(t, b.Scan(int64(2)) != nil)
+
+	now := time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC)
+	var tm Time
+	assert(t, tm.Scan(now) == nil)
+	assertEqual(t, tm, NewTime(now))
+	assert(t, tm.Scan("2015-01-02 03:04:05") == nil)
+	assertEqual(t, tm, NewTime(now))
+	assert(t, tm.Scan(nil) == nil)
+	assertEqual(t, tm.Null, true)
+}
+
 type withMetaStar struct {
 	AField    String
 	AllFields map[string]interface{} `meta:"*"`
@@ -236,3 +295,190 @@ func TestObjectValuer(t *testing.T) {
 }
 
 // TODO: test default values
+
+type withDefinitionErrors struct {
+	A String `meta_min_runes:"abc"`
+	B Int64  `meta_required:"ture"`
+	C int    // not a Valuer
+	D []int  // slice of non Valuers
+	E Int64  `meta_default:"abc"`
+	F []struct {
+		G Float64 `meta_max:"x"`
+	} `meta_min_length:"-"`
+	H []struct {
+		I Uint64 `meta_in:"1,b"`
+	}
+	J String `meta:"b"`
+	K String `meta_from:"heder"`
+}
+
+func TestNewDecoderE(t *testing.T) {
+	d, err := NewDecoderE(withMetaName{}, DecoderOptions{})
+	assert(t, d != nil)
+	assertEqual(t, err, nil)
+
+	d, err = NewDecoderE(withDefinitionErrors{}, DecoderOptions{})
+	assert(t, d == nil)
+	errs, ok := err.(DefinitionErrors)
+	assert(t, ok)
+
+	var paths []string
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+	assertEqual(t, paths, []string{"a", "b", "c", "d", "e", "f", "f[].g", "h[].i", "b", "k"})
+	assertEqual(t, errs[8].Error(), `b: duplicate meta name "b"`)
+
+	assertEqual(t, errs[9].Error(), `k: unknown source "heder" in meta_from`)
+
+	type withSources struct {
+		A String `meta_in_location:"path"`
+		B String `meta_from:"defaults.json,query"`
+		C String `meta_from:"replay"`
+	}
+	_, err = NewDecoderE(withSources{}, DecoderOptions{SourceNames: []string{"defaults.json"}, SourcePrecedence: []string{"replay"}})
+	assertEqual(t, err, nil)
+
+	_, err = NewDecoderE(1, DecoderOptions{})
+	assertEqual(t, err.Error(), "meta: invalid struct definition: expect ptr to struct or struct, got int")
+}
+
+func TestMustNewDecoder(t *testing.T) {
+	defer func() {
+		r := recover()
+		assert(t, r != nil)
+	}()
+	MustNewDecoder(withDefinitionErrors{}, DecoderOptions{})
+}
+
+func TestUnsupportedFieldIsSkipped(t *testing.T) {
+	var inputs struct {
+		A String
+		B int
+		c String
+	}
+	e := NewDecoder(&inputs).DecodeJSON(&inputs, []byte(`{"a":"1","b":2,"c":"3"}`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, "1")
+	assertEqual(t, inputs.B, 0)
+	assertEqual(t, inputs.c.Val, "")
+}
+
+type withAllTypes struct {
+	S  String
+	I  Int64
+	U  Uint64
+	F  Float64
+	B  Bool
+	T  Time
+	IS Int64Slice
+	SS StringSlice
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	now := time.Date(2015, 1, 2, 3, 4, 5, 600, time.UTC)
+	in := withAllTypes{
+		S:  NewString(""),
+		I:  NewInt64(-1),
+		U:  NewUint64(math.MaxUint64),
+		F:  NewFloat64(1.5),
+		B:  Bool{Nullity: Nullity{true}, Presence: Presence{true}},
+		T:  NewTime(now),
+		IS: Int64Slice{Val: []int64{1, 2}},
+		SS: StringSlice{Val: []string{"a", "b"}},
+	}
+	j, err := json.Marshal(in)
+	assert(t, err == nil)
+	assertEqual(t, string(j), `{"S":"","I":-1,"U":18446744073709551615,"F":1.5,"B":null,"T":"2015-01-02T03:04:05.0000006Z","IS":[1,2],"SS":["a","b"]}`)
+
+	var out withAllTypes
+	err = json.Unmarshal(j, &out)
+	assert(t, err == nil)
+	assertEqual(t, out, in)
+
+	// missing keys are not present
+	out = withAllTypes{}
+	err = json.Unmarshal([]byte(`{"S":"a"}`), &out)
+	assert(t, err == nil)
+	assertEqual(t, out.S, NewString("a"))
+	assertEqual(t, out.I.Present, false)
+
+	err = json.Unmarshal([]byte(`{"I":"a"}`), &out)
+	assertEqual(t, err, ErrInt)
+
+	err = json.Unmarshal([]byte(`{"IS":[1,"b"]}`), &out)
+	assertEqual(t, err, ErrorSlice{nil, ErrInt})
+}
+
+func TestUnmarshalText(t *testing.T) {
+	var i Int64
+	assert(t, i.UnmarshalText([]byte("12")) == nil)
+	assertEqual(t, i, NewInt64(12))
+	assertEqual(t, i.UnmarshalText([]byte("a")), ErrInt)
+
+	var s String
+	assert(t, s.UnmarshalText([]byte(" a ")) == nil)
+	assertEqual(t, s, NewString("a"))
+
+	var tm Time
+	assert(t, tm.UnmarshalText([]byte("2015-01-02T03:04:05Z")) == nil)
+	assertEqual(t, tm, NewTime(time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+	var ss StringSlice
+	assert(t, ss.UnmarshalText([]byte("a,,b")) == nil)
+	assertEqual(t, ss.Val, []string{"a", "b"})
+
+	var is Int64Slice
+	assert(t, is.UnmarshalText([]byte("")) == nil)
+	assertEqual(t, is.Val, []int64(nil))
+}
+
+type withRest struct {
+	AField String
+	Rest   map[string]interface{} `meta:"*rest"`
+}
+
+func TestMetaRest(t *testing.T) {
+	var inputs withRest
+	e := NewDecoder(&inputs).Decode(&inputs, url.Values{"cf_other_field": {"Another field"}}, []byte(`{"a_field": "A field", "cf_numeric_field": 12}`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.AField.Val, "A field")
+	assertEqual(t, inputs.Rest, map[string]interface{}{
+		"cf_numeric_field": json.Number("12"),
+		"cf_other_field":   "Another field",
+	})
+
+	inputs = withRest{}
+	e = NewDecoder(&inputs).DecodeJSON(&inputs, []byte(`{"a_field": "A field"}`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.Rest, map[string]interface{}(nil))
+}
+
+func TestDisallowUnknown(t *testing.T) {
+	d := NewDecoderWithOptions(&nested{}, DecoderOptions{DisallowUnknown: true})
+
+	var inputs nested
+	e := d.DecodeJSON(&inputs, []byte(`{"a":"1","emial":"x","c":{"d":"2","f":3}}`))
+	assertEqual(t, e, ErrorHash{"emial": ErrUnknownField, "c": ErrorHash{"f": ErrUnknownField}})
+
+	inputs = nested{}
+	e = d.DecodeValues(&inputs, url.Values{"a": {"1"}, "c.d": {"2"}, "c.x": {"3"}})
+	assertEqual(t, e, ErrorHash{"c": ErrorHash{"x": ErrUnknownField}})
+
+	inputs = nested{}
+	e = d.DecodeValues(&inputs, url.Values{"a": {"1"}, "c.d": {"2"}})
+	assertEqual(t, e, ErrorHash(nil))
+
+	// slices of structs and embedded structs
+	e = NewDecoderWithOptions(&withSliceOfHashes{}, DecoderOptions{DisallowUnknown: true}).DecodeJSON(&withSliceOfHashes{}, []byte(`{"a":[{"a":"1","c":2}]}`))
+	assertEqual(t, e, ErrorHash{"a": ErrorSlice{ErrorHash{"c": ErrUnknownField}}})
+
+	e = NewDecoderWithOptions(&embedder{}, DecoderOptions{DisallowUnknown: true}).DecodeJSON(&embedder{}, []byte(`{"a":"1","b":"2","c":"3"}`))
+	assertEqual(t, e, ErrorHash(nil))
+
+	// a catch-all field accepts anything
+	var rest withRest
+	e = NewDecoderWithOptions(&rest, DecoderOptions{DisallowUnknown: true}).DecodeJSON(&rest, []byte(`{"b":"1"}`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, rest.Rest, map[string]interface{}{"b": "1"})
+}
@@ -0,0 +1,43 @@
+// Package metaflag declares command-line flags with a meta struct, so a CLI's flags get the same names, docs,
+// defaults and validation as any other meta input.
+//
+// Every key the decoder accepts (see meta.Decoder.FormSchema) is a flag: a field DB.MaxConns with NameMapping's
+// default naming is -db.max_conns. The doc tag is the flag's usage and meta_default is its default. A slice of values,
+// or an Int64Slice or StringSlice, can be repeated: -tags=a -tags=b. Slices of structs aren't flags.
+// See meta.Decoder.RegisterFlags, which this is built on.
+package metaflag
+
+import (
+	"flag"
+	"net/url"
+
+	"github.com/gocraft/meta"
+)
+
+// Flags are the flags of a decoder, registered on a flag.FlagSet by Register.
+type Flags struct {
+	decoder *meta.Decoder
+	values  url.Values
+}
+
+// Register defines a flag on fs for every key that d decodes.
+func Register(fs *flag.FlagSet, d *meta.Decoder) *Flags {
+	return &Flags{decoder: d, values: d.RegisterFlags(fs)}
+}
+
+// Decode decodes the flags that were set into dest, after the flag set has been parsed.
+// Flags that weren't set get their meta_default.
+func (f *Flags) Decode(dest interface{}) meta.ErrorHash {
+	return f.decoder.DecodeValues(dest, f.values)
+}
+
+// Parse registers d's flags on a new flag set named name, parses args, eg os.Args[1:], and decodes them into dest.
+// A malformed command line is an error, and invalid values are in the ErrorHash.
+func Parse(name string, d *meta.Decoder, dest interface{}, args []string) (meta.ErrorHash, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	f := Register(fs, d)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return f.Decode(dest), nil
+}
@@ -0,0 +1,88 @@
+package metaflag
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gocraft/meta"
+)
+
+func assertEqual(t *testing.T, a interface{}, b interface{}) {
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Expected %v (type %v) - Got %v (type %v)", b, reflect.TypeOf(b), a, reflect.TypeOf(a))
+	}
+}
+
+type serve struct {
+	Addr    meta.String `meta_default:":8080" doc:"Address to listen on"`
+	Verbose meta.Bool
+	DB      struct {
+		MaxConns meta.Int64 `meta_min:"1" meta_default:"5"`
+	} `meta:"db"`
+	Tags    meta.StringSlice
+	Ports   []meta.Int64
+	Servers []struct {
+		Name meta.String
+	}
+}
+
+var serveDecoder = meta.NewDecoder(&serve{})
+
+func TestParse(t *testing.T) {
+	var inputs serve
+	e, err := Parse("serve", serveDecoder, &inputs, []string{"-verbose", "-db.max_conns=10", "-tags=a,b", "-tags=c", "-ports=80", "-ports", "443"})
+	assertEqual(t, err, nil)
+	assertEqual(t, e, meta.ErrorHash(nil))
+	assertEqual(t, inputs.Addr.Val, ":8080")
+	assertEqual(t, inputs.Verbose.Val, true)
+	assertEqual(t, inputs.DB.MaxConns.Val, int64(10))
+	assertEqual(t, inputs.Tags.Val, []string{"a", "b", "c"})
+	assertEqual(t, len(inputs.Ports), 2)
+
+	e, err = Parse("serve", serveDecoder, &serve{}, []string{"-db.max_conns=0"})
+	assertEqual(t, err, nil)
+	assertEqual(t, e, meta.ErrorHash{"db": meta.ErrorHash{"max_conns": meta.ErrMin}})
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	Register(fs, serveDecoder)
+	assertEqual(t, fs.Parse([]string{"-servers.0.name=x"}) != nil, true)
+}
+
+func TestUsage(t *testing.T) {
+	var usage bytes.Buffer
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(&usage)
+	Register(fs, serveDecoder)
+	fs.PrintDefaults()
+
+	assertEqual(t, strings.Contains(usage.String(), "Address to listen on (default :8080)"), true)
+	assertEqual(t, strings.Contains(usage.String(), "(default 5)"), true)
+	assertEqual(t, fs.Lookup("verbose") != nil, true)
+	assertEqual(t, fs.Lookup("ports") != nil, true)
+	assertEqual(t, fs.Lookup("servers.name"), (*flag.Flag)(nil))
+}
+
+func TestValue(t *testing.T) {
+	var n meta.Int64
+	fs := flag.NewFlagSet("n", flag.ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	fs.Var(&n, "n", "")
+	assertEqual(t, fs.Parse([]string{"-n=7"}), nil)
+	assertEqual(t, n.Val, int64(7))
+	assertEqual(t, n.String(), "7")
+	assertEqual(t, fs.Parse([]string{"-n=x"}) != nil, true)
+
+	var debug meta.Bool
+	var ids meta.Int64Slice
+	fs = flag.NewFlagSet("b", flag.ContinueOnError)
+	fs.Var(&debug, "debug", "")
+	fs.Var(&ids, "ids", "")
+	assertEqual(t, fs.Parse([]string{"-debug", "-ids=1,2", "-ids=3"}), nil)
+	assertEqual(t, debug.Val, true)
+	assertEqual(t, ids.Val, []int64{1, 2, 3})
+	assertEqual(t, ids.String(), "1,2,3")
+}
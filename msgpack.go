@@ -0,0 +1,172 @@
+package meta
+
+import (
+	"math"
+	"time"
+)
+
+// DecodeMsgPack decodes a MessagePack value, like DecodeJSON. Input that can't be parsed is ErrMalformed.
+func (d *Decoder) DecodeMsgPack(dest interface{}, b []byte) ErrorHash {
+	return d.decodeBody(dest, b, NewMsgPackSource)
+}
+
+// NewMsgPackSource reads a MessagePack value. Integers, floats, binary and timestamps (extension type -1) are passed to
+// the fields as they are: Int64 and Float64 take numbers, Bytes takes binary and Time takes timestamps. Map keys must
+// be strings or integers, and other extension types are malformed.
+func NewMsgPackSource(b []byte) Source {
+	return newBinarySource(b, parseMsgPack)
+}
+
+func parseMsgPack(r *binaryReader) (interface{}, error) {
+	c, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c <= 0x7f: // positive fixint
+		return int64(c), nil
+	case c >= 0xe0: // negative fixint
+		return int64(int8(c)), nil
+	case c <= 0x8f: // fixmap
+		return parseMsgPackMap(r, uint64(c&0x0f))
+	case c <= 0x9f: // fixarray
+		return parseMsgPackArray(r, uint64(c&0x0f))
+	case c <= 0xbf: // fixstr
+		b, err := r.next(uint64(c & 0x1f))
+		return string(b), err
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6: // bin 8, 16, 32
+		n, err := r.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return r.bytes(n)
+	case 0xc7, 0xc8, 0xc9: // ext 8, 16, 32
+		n, err := r.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return parseMsgPackExt(r, n)
+	case 0xca:
+		u, err := r.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := r.uint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf: // uint 8, 16, 32, 64
+		u, err := r.uint(1 << (c - 0xcc))
+		return binaryInt(u), err
+	case 0xd0, 0xd1, 0xd2, 0xd3: // int 8, 16, 32, 64
+		size := uint(8) << (c - 0xd0)
+		u, err := r.uint(int(size / 8))
+		return int64(u<<(64-size)) >> (64 - size), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8: // fixext 1, 2, 4, 8, 16
+		return parseMsgPackExt(r, 1<<(c-0xd4))
+	case 0xd9, 0xda, 0xdb: // str 8, 16, 32
+		n, err := r.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.next(n)
+		return string(b), err
+	case 0xdc, 0xdd: // array 16, 32
+		n, err := r.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return parseMsgPackArray(r, n)
+	case 0xde, 0xdf: // map 16, 32
+		n, err := r.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return parseMsgPackMap(r, n)
+	}
+	return nil, binaryError("msgpack: invalid type 0xc1")
+}
+
+func parseMsgPackArray(r *binaryReader, n uint64) (interface{}, error) {
+	if err := r.nest(); err != nil {
+		return nil, err
+	}
+	defer r.unnest()
+
+	out := make([]interface{}, 0, r.capacity(n))
+	for i := uint64(0); i < n; i++ {
+		el, err := parseMsgPack(r)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, el)
+	}
+	return out, nil
+}
+
+func parseMsgPackMap(r *binaryReader, n uint64) (interface{}, error) {
+	if err := r.nest(); err != nil {
+		return nil, err
+	}
+	defer r.unnest()
+
+	out := make(map[string]interface{}, r.capacity(n))
+	for i := uint64(0); i < n; i++ {
+		k, err := parseMsgPack(r)
+		if err != nil {
+			return nil, err
+		}
+		key, err := binaryKey(k)
+		if err != nil {
+			return nil, err
+		}
+		if out[key], err = parseMsgPack(r); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// parseMsgPackExt reads an extension with n bytes of data. Only timestamps are supported.
+func parseMsgPackExt(r *binaryReader, n uint64) (interface{}, error) {
+	typ, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if int8(typ) != -1 {
+		return nil, binaryError("msgpack: unsupported extension type")
+	}
+
+	sub := &binaryReader{b: data}
+	var sec int64
+	var nsec uint64
+	switch n {
+	case 4:
+		u, _ := sub.uint(4)
+		sec = int64(u)
+	case 8:
+		u, _ := sub.uint(8)
+		nsec, sec = u>>34, int64(u&(1<<34-1))
+	case 12:
+		nsec, _ = sub.uint(4)
+		u, _ := sub.uint(8)
+		sec = int64(u)
+	default:
+		return nil, binaryError("msgpack: invalid timestamp")
+	}
+	if nsec > 999999999 {
+		return nil, binaryError("msgpack: invalid timestamp")
+	}
+	return time.Unix(sec, int64(nsec)).UTC(), nil
+}
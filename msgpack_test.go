@@ -0,0 +1,102 @@
+package meta
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+type withBinary struct {
+	Name  String `meta_required:"true"`
+	Count Int64
+	Big   Uint64
+	Ratio Float64
+	Data  Bytes
+	When  Time
+	Tags  []String
+	Ok    Bool
+	Items []struct {
+		Price Float64
+	}
+}
+
+var withBinaryDecoder = NewDecoder(&withBinary{})
+
+func assertBinary(t *testing.T, inputs withBinary) {
+	assertEqual(t, inputs.Name.Val, "x")
+	assertEqual(t, inputs.Count.Val, int64(2))
+	assertEqual(t, inputs.Big.Val, uint64(math.MaxUint64))
+	assertEqual(t, inputs.Ratio.Val, 1.5)
+	assertEqual(t, inputs.Data.Val, []byte{1, 2, 3})
+	assertEqual(t, inputs.When.Val, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	assertEqual(t, len(inputs.Tags), 2)
+	assertEqual(t, inputs.Tags[1].Val, "b")
+	assertEqual(t, inputs.Ok.Val, true)
+	assertEqual(t, len(inputs.Items), 1)
+	assertEqual(t, inputs.Items[0].Price.Val, -2.0)
+}
+
+var msgpackBinary = "\x89" +
+	"\xa4name\xa1x" +
+	"\xa5count\x02" +
+	"\xa3big\xcf\xff\xff\xff\xff\xff\xff\xff\xff" +
+	"\xa5ratio\xcb\x3f\xf8\x00\x00\x00\x00\x00\x00" +
+	"\xa4data\xc4\x03\x01\x02\x03" +
+	"\xa4when\xd6\xff\x5e\x0d\x5d\xa5" +
+	"\xa4tags\x92\xa1a\xa1b" +
+	"\xa2ok\xc3" +
+	"\xa5items\x91\x81\xa5price\xd0\xfe"
+
+func TestDecodeMsgPack(t *testing.T) {
+	var inputs withBinary
+	e := withBinaryDecoder.DecodeMsgPack(&inputs, []byte(msgpackBinary))
+	assertEqual(t, e, ErrorHash(nil))
+	assertBinary(t, inputs)
+
+	e = withBinaryDecoder.DecodeMsgPack(&withBinary{}, []byte("\x83\xa4name\xa1x\xa5count\xa3abc\xa3big\xff"))
+	assertEqual(t, e, ErrorHash{"count": ErrInt, "big": ErrInt})
+
+	e = withBinaryDecoder.DecodeMsgPack(&withBinary{}, []byte("\x82\xa4name\xa1x\xa5count\xcf\xff\xff\xff\xff\xff\xff\xff\xff"))
+	assertEqual(t, e, ErrorHash{"count": ErrIntRange})
+
+	e = withBinaryDecoder.DecodeMsgPack(&withBinary{}, []byte(msgpackBinary[:len(msgpackBinary)-1]))
+	assertEqual(t, e, ErrorHash{"error": ErrMalformed})
+
+	e = withBinaryDecoder.DecodeMsgPack(&withBinary{}, nil)
+	assertEqual(t, e, ErrorHash{"name": ErrRequired})
+}
+
+func TestParseMsgPack(t *testing.T) {
+	tests := []struct {
+		msgpack string
+		want    interface{}
+	}{
+		{"\xd3\x80\x00\x00\x00\x00\x00\x00\x00", int64(math.MinInt64)},
+		{"\xd1\xff\x00", int64(-256)},
+		{"\xca\x3f\xc0\x00\x00", 1.5},
+		{"\xd9\x03abc", "abc"},
+		{"\xdc\x00\x01\xc0", []interface{}{nil}},
+		{"\x81\x01\xa1x", map[string]interface{}{"1": "x"}},
+		{"\xd7\xff\x00\x00\x00\x14\x00\x00\x00\x01", time.Unix(1, 5).UTC()},
+		{"\xc7\x0c\xff\x00\x00\x00\x00\xff\xff\xff\xff\xff\xff\xff\xff", time.Unix(-1, 0).UTC()},
+	}
+	for _, test := range tests {
+		got, err := parseMsgPack(&binaryReader{b: []byte(test.msgpack)})
+		if err != nil {
+			t.Errorf("%x: %s", test.msgpack, err)
+			continue
+		}
+		assertEqual(t, got, test.want)
+	}
+
+	for _, msgpack := range []string{"\xc1", "\xd4\x01\x00", "\x81\xc0\x01", "\xdd\xff\xff\xff\xff", "\xa5abc", strings.Repeat("\x91", maxBinaryDepth+1) + "\x00"} {
+		if _, err := parseMsgPack(&binaryReader{b: []byte(msgpack)}); err == nil {
+			t.Errorf("%x: expected an error", msgpack)
+		}
+	}
+}
+
+func TestDecodeRequestMsgPack(t *testing.T) {
+	assertDecodesRequest(t, "application/x-msgpack", "\x82\xa1a\xa11\xa1c\x81\xa1d\x03")
+}
@@ -0,0 +1,164 @@
+// Package openapi generates OpenAPI 3.1 parameter and requestBody objects from a meta.Decoder,
+// so an endpoint's documentation comes from the same struct that validates its input.
+//
+// Query and form inputs use the dotted keys that meta decodes (see meta.Decoder.FormSchema).
+package openapi
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gocraft/meta"
+)
+
+type Location string
+
+const (
+	Query Location = "query"
+	Form  Location = "form"
+	JSON  Location = "json"
+)
+
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeForm = "application/x-www-form-urlencoded"
+)
+
+type Parameter struct {
+	Name        string       `json:"name"`
+	In          string       `json:"in"`
+	Description string       `json:"description,omitempty"`
+	Required    bool         `json:"required,omitempty"`
+	Style       string       `json:"style,omitempty"`
+	Explode     *bool        `json:"explode,omitempty"`
+	Schema      *meta.Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *meta.Schema `json:"schema"`
+}
+
+// Parameters returns a parameter for every key the decoder accepts outside the body, sorted by name.
+// A field's meta_from picks where the parameter is: "path", "header", "cookie" or, by default, "query".
+// Fields that are only read from the body are left to NewRequestBody.
+func Parameters(d *meta.Decoder) []*Parameter {
+	schema := d.FormSchema()
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]*Parameter, 0, len(names))
+	for _, name := range names {
+		in := paramLocation(fieldFrom(d, name))
+		if in == "" {
+			continue
+		}
+
+		prop := schema.Properties[name]
+		param := &Parameter{
+			Name:        name,
+			In:          in,
+			Description: prop.Description,
+			Required:    contains(schema.Required, name),
+			Schema:      prop,
+		}
+		switch in {
+		case meta.SourcePath:
+			param.Required = true // OpenAPI requires path parameters
+		case meta.SourceHeader:
+			param.Name = meta.HeaderKey(name)
+		}
+		if prop.Type == "array" {
+			// Int64Slice and StringSlice take a comma separated value unless FormSlices is FormSlicesRepeat.
+			explode := d.Options.FormSlices == meta.FormSlicesRepeat
+			param.Style = "form"
+			param.Explode = &explode
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// NewRequestBody returns a requestBody for a JSON or form body. It returns nil for Query, whose inputs are Parameters.
+func NewRequestBody(d *meta.Decoder, loc Location) *RequestBody {
+	var schema *meta.Schema
+	var contentType string
+
+	switch loc {
+	case Form:
+		schema = d.FormSchema()
+		contentType = ContentTypeForm
+		for name := range schema.Properties {
+			if from := fieldFrom(d, name); len(from) > 0 && !contains(from, meta.SourceBody) {
+				delete(schema.Properties, name)
+				schema.Required = remove(schema.Required, name)
+			}
+		}
+	case JSON:
+		schema = d.JSONSchema()
+		contentType = ContentTypeJSON
+	default:
+		// Query inputs aren't a body; see Parameters.
+		return nil
+	}
+	schema.Schema = "" // the dialect is implied by the OpenAPI document
+
+	return &RequestBody{
+		Required: len(schema.Required) > 0,
+		Content:  map[string]MediaType{contentType: {Schema: schema}},
+	}
+}
+
+func contains(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
+
+// paramLocation returns the "in" of a parameter read from the sources in from, or "" if it's only read from the body.
+func paramLocation(from []string) string {
+	if len(from) == 0 || contains(from, meta.SourceQuery) {
+		return string(Query)
+	}
+	for _, in := range []string{meta.SourcePath, meta.SourceHeader, meta.SourceCookie} {
+		if contains(from, in) {
+			return in
+		}
+	}
+	return ""
+}
+
+// fieldFrom returns the meta_from of the top level field that the dotted key name belongs to.
+func fieldFrom(d *meta.Decoder, name string) []string {
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[:i]
+	}
+	for _, dfield := range d.Fields {
+		if dfield.Name == name {
+			return dfield.From
+		}
+	}
+	return nil
+}
+
+func remove(strs []string, s string) []string {
+	kept := strs[:0]
+	for _, str := range strs {
+		if str != s {
+			kept = append(kept, str)
+		}
+	}
+	return kept
+}
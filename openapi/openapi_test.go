@@ -0,0 +1,94 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/gocraft/meta"
+)
+
+func assertEqual(t *testing.T, a interface{}, b interface{}) {
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Expected %v (type %v) - Got %v (type %v)", b, reflect.TypeOf(b), a, reflect.TypeOf(a))
+	}
+}
+
+type search struct {
+	Q    meta.String `meta_required:"true" doc:"What to search for"`
+	Kind meta.String `meta_in:"user,post"`
+	Page struct {
+		Size meta.Int64 `meta_required:"true" meta_min:"1" meta_max:"100"`
+	} `meta_required:"true"`
+	Ids   meta.Int64Slice
+	Tags  []meta.String `meta_min_length:"1"`
+	Items []struct {
+		Name meta.String `meta_required:"true"`
+	}
+}
+
+var searchDecoder = meta.NewDecoder(&search{})
+
+func TestParameters(t *testing.T) {
+	j, err := json.Marshal(Parameters(searchDecoder))
+	assertEqual(t, err, nil)
+	assertEqual(t, string(j), `[`+
+		`{"name":"ids","in":"query","style":"form","explode":false,"schema":{"type":"array","items":{"type":"integer"}}},`+
+		`{"name":"items.0.name","in":"query","schema":{"type":"string"}},`+
+		`{"name":"kind","in":"query","schema":{"type":"string","enum":["user","post"]}},`+
+		`{"name":"page.size","in":"query","required":true,"schema":{"type":"integer","minimum":1,"maximum":100}},`+
+		`{"name":"q","in":"query","description":"What to search for","required":true,"schema":{"type":"string","description":"What to search for"}},`+
+		`{"name":"tags.0","in":"query","required":true,"schema":{"type":"string"}}]`)
+}
+
+func TestFormRequestBody(t *testing.T) {
+	body := NewRequestBody(searchDecoder, Form)
+	assertEqual(t, body.Required, true)
+	schema := body.Content[ContentTypeForm].Schema
+	assertEqual(t, schema.Required, []string{"page.size", "q", "tags.0"})
+	assertEqual(t, len(schema.Properties), 6)
+}
+
+func TestQueryRequestBody(t *testing.T) {
+	assertEqual(t, NewRequestBody(searchDecoder, Query), (*RequestBody)(nil))
+}
+
+func TestJSONRequestBody(t *testing.T) {
+	body := NewRequestBody(searchDecoder, JSON)
+	j, err := json.Marshal(body)
+	assertEqual(t, err, nil)
+	assertEqual(t, string(j), `{"required":true,"content":{"application/json":{"schema":{"type":"object","properties":{`+
+		`"ids":{"type":"array","items":{"type":"integer"}},`+
+		`"items":{"type":"array","items":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]}},`+
+		`"kind":{"type":"string","enum":["user","post"]},`+
+		`"page":{"type":"object","properties":{"size":{"type":"integer","minimum":1,"maximum":100}},"required":["size"]},`+
+		`"q":{"type":"string","description":"What to search for"},`+
+		`"tags":{"type":"array","items":{"type":"string"},"minItems":1}},`+
+		`"required":["q","page"]}}}}`)
+}
+
+type getUser struct {
+	Id      meta.Int64  `meta_from:"path"`
+	Trace   meta.String `meta_from:"header"`
+	Session meta.String `meta_from:"cookie"`
+	Fields  meta.String
+	Name    meta.String `meta_from:"body" meta_required:"true"`
+}
+
+func TestParametersLocations(t *testing.T) {
+	d := meta.NewDecoder(&getUser{})
+	j, err := json.Marshal(Parameters(d))
+	assertEqual(t, err, nil)
+	assertEqual(t, string(j), `[`+
+		`{"name":"fields","in":"query","schema":{"type":"string"}},`+
+		`{"name":"id","in":"path","required":true,"schema":{"type":"integer"}},`+
+		`{"name":"session","in":"cookie","schema":{"type":"string"}},`+
+		`{"name":"Trace","in":"header","schema":{"type":"string"}}]`)
+
+	schema := NewRequestBody(d, Form).Content[ContentTypeForm].Schema
+	assertEqual(t, schema.Required, []string{"name"})
+	assertEqual(t, len(schema.Properties), 2)
+
+	schema = NewRequestBody(d, JSON).Content[ContentTypeJSON].Schema
+	assertEqual(t, len(schema.Properties), 2)
+}
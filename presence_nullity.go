@@ -35,3 +35,13 @@ func (n *Nullity) SetNullity(err Errorable) Errorable {
 	}
 	return err
 }
+
+// IsPresent reports whether a value was set. It lets code that only has an interface, like Encoder, see the Presence of any type that embeds it.
+func (p Presence) IsPresent() bool {
+	return p.Present
+}
+
+// IsNull reports whether a value was explicitly set to null.
+func (n Nullity) IsNull() bool {
+	return n.Null
+}
@@ -0,0 +1,199 @@
+package meta
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	ContentTypeJSON      = "application/json"
+	ContentTypeForm      = "application/x-www-form-urlencoded"
+	ContentTypeMultipart = "multipart/form-data"
+	ContentTypeYAML      = "application/yaml"
+	ContentTypeXML       = "application/xml"
+	ContentTypeMsgPack   = "application/msgpack"
+	ContentTypeCBOR      = "application/cbor"
+)
+
+// defaultMaxMultipartMemory is the same as net/http's default for ParseMultipartForm.
+const defaultMaxMultipartMemory = 32 << 20
+
+// DecodeRequest decodes the body of req, picking JSON, YAML, XML, MessagePack, CBOR, url-encoded or multipart form by its Content-Type, along with its URL query.
+// The body is SourceBody and the query is SourceQuery, for SourcePrecedence and meta_from. Like Decode, the body is used first by default.
+//
+// A body with any other Content-Type is ErrUnsupportedMediaType. A form body that can't be parsed is ErrMalformedBody, and JSON,
+// YAML, XML, MessagePack or CBOR that can't be parsed is ErrMalformed, as with Decode. The body is limited to MaxBodyBytes.
+//
+// Headers and cookies are decoded too, but only into fields that ask for them with meta_from:"header" or meta_from:"cookie".
+// Other sources, eg NewPathSource with the router's path parameters, can be passed in extra.
+func (d *Decoder) DecodeRequest(dest interface{}, req *http.Request, extra ...Source) ErrorHash {
+	body, err := d.requestBody(req)
+	if err != nil {
+		return ErrorHash{"error": err}
+	}
+	query := d.formValues(req.URL.Query())
+
+	var bodySrc Source
+	switch b := body.(type) {
+	case []byte:
+		if err := d.checkInput(b, query); err != nil {
+			return ErrorHash{"error": err}
+		}
+		bodySrc = NewJSONSource(b)
+	case url.Values:
+		if err := d.checkInput(nil, b, query); err != nil {
+			return ErrorHash{"error": err}
+		}
+		bodySrc = NewFormValueSource(b)
+	case Source:
+		if err := d.checkInput(nil, query); err != nil {
+			return ErrorHash{"error": err}
+		}
+		if err := d.checkTree(b); err != nil {
+			return ErrorHash{"error": err}
+		}
+		bodySrc = b
+	case map[string]interface{}:
+		if err := d.checkInput(nil, query); err != nil {
+			return ErrorHash{"error": err}
+		}
+		if err := d.checkLimits(valueStats(b)); err != nil {
+			return ErrorHash{"error": err}
+		}
+		bodySrc = &mapSource{value: b, form: true}
+	default:
+		if err := d.checkInput(nil, query); err != nil {
+			return ErrorHash{"error": err}
+		}
+		bodySrc = &emptySource{}
+	}
+
+	src := []Source{
+		NewNamedSource(SourceBody, bodySrc),
+		NewNamedSource(SourceQuery, NewFormValueSource(query)),
+		NewHeaderSource(req.Header),
+		NewCookieSource(req.Cookies()),
+	}
+	return d.decodeSource(reflect.ValueOf(dest), NewMergedSource(append(src, extra...)...))
+}
+
+// readBodySource reads a body in a format that's parsed into a Source, eg YAML.
+func readBodySource(body io.Reader, newSource func([]byte) Source) (Source, Errorable) {
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, readError(err)
+	}
+	return newSource(b), nil
+}
+
+// requestBody reads the body of req and returns its JSON as []byte, its url-encoded form as url.Values,
+// its multipart form as a tree like formValueTree's, a Source for other formats like YAML, XML and the binary ones, or nil if there's no body.
+func (d *Decoder) requestBody(req *http.Request) (interface{}, Errorable) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	contentType := req.Header.Get("Content-Type")
+	if contentType == "" {
+		if req.ContentLength == 0 {
+			return nil, nil
+		}
+		return nil, ErrUnsupportedMediaType
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	body := req.Body
+	if d.Options.MaxBodyBytes > 0 {
+		body = http.MaxBytesReader(nil, body, d.Options.MaxBodyBytes)
+	}
+
+	switch {
+	case mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json"):
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, readError(err)
+		}
+		return b, nil
+	case mediaType == ContentTypeYAML || mediaType == "application/x-yaml" || mediaType == "text/yaml" || strings.HasSuffix(mediaType, "+yaml"):
+		return readBodySource(body, NewYAMLSource)
+	case mediaType == ContentTypeXML || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return readBodySource(body, NewXMLSource)
+	case mediaType == ContentTypeMsgPack || mediaType == "application/x-msgpack" || mediaType == "application/vnd.msgpack":
+		return readBodySource(body, NewMsgPackSource)
+	case mediaType == ContentTypeCBOR || strings.HasSuffix(mediaType, "+cbor"):
+		return readBodySource(body, NewCBORSource)
+	case mediaType == ContentTypeForm:
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, readError(err)
+		}
+		values, err := url.ParseQuery(string(b))
+		if err != nil {
+			return nil, ErrMalformedBody
+		}
+		return d.formValues(values), nil
+	case mediaType == ContentTypeMultipart:
+		req.Body = body
+		maxMemory := d.Options.MaxMultipartMemory
+		if maxMemory <= 0 {
+			maxMemory = defaultMaxMultipartMemory
+		}
+		if err := req.ParseMultipartForm(maxMemory); err != nil {
+			return nil, readError(err)
+		}
+		return multipartTree(req.MultipartForm, d.Options.FormBrackets), nil
+	}
+
+	return nil, ErrUnsupportedMediaType
+}
+
+func readError(err error) Errorable {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return ErrBodyTooLarge
+	}
+	return ErrMalformedBody
+}
+
+// multipartTree is the values and files of a multipart form as nested maps with dotted keys, like formValueTree.
+// Files are always a []interface{} of *multipart.FileHeader, so a single upload can fill a []File.
+// With brackets, keys are rewritten as for FormBrackets.
+func multipartTree(form *multipart.Form, brackets bool) map[string]interface{} {
+	values := form.Value
+	if brackets {
+		values = bracketValues(values)
+	}
+	root := formValueTree(values)
+
+	keys := make([]string, 0, len(form.File))
+	for key := range form.File {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	files := make(map[string][]interface{}, len(form.File))
+	for _, key := range keys {
+		fileKey := key
+		if brackets {
+			fileKey = bracketKey(key)
+		}
+		for _, fh := range form.File[key] {
+			files[fileKey] = append(files[fileKey], fh)
+		}
+	}
+	for key, fhs := range files {
+		setDotted(root, key, fhs)
+	}
+	return root
+}
@@ -0,0 +1,186 @@
+package meta
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type withRequest struct {
+	A String
+	B Int64
+	C struct {
+		D String
+	}
+}
+
+var withRequestDecoder = NewDecoder(&withRequest{})
+
+// assertDecodesRequest checks that a body of the given type, with a=1 and c.d=3, decodes into withRequest along with a query.
+func assertDecodesRequest(t *testing.T, contentType string, body string) {
+	req := httptest.NewRequest("POST", "/?b=2", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+
+	var inputs withRequest
+	e := withRequestDecoder.DecodeRequest(&inputs, req)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, "1")
+	assertEqual(t, inputs.B.Val, int64(2))
+	assertEqual(t, inputs.C.D.Val, "3")
+}
+
+func TestDecodeRequestJSON(t *testing.T) {
+	req := httptest.NewRequest("POST", "/?b=2", strings.NewReader(`{"a":"1","c":{"d":"3"}}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	var inputs withRequest
+	e := withRequestDecoder.DecodeRequest(&inputs, req)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, "1")
+	assertEqual(t, inputs.B.Val, int64(2))
+	assertEqual(t, inputs.C.D.Val, "3")
+
+	req = httptest.NewRequest("POST", "/", strings.NewReader(`{"a":`))
+	req.Header.Set("Content-Type", "application/vnd.api+json")
+	e = withRequestDecoder.DecodeRequest(&inputs, req)
+	assertEqual(t, e, ErrorHash{"error": ErrMalformed})
+}
+
+func TestDecodeRequestForm(t *testing.T) {
+	req := httptest.NewRequest("POST", "/?b=2&a=query", strings.NewReader(`a=1&c.d=3`))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var inputs withRequest
+	e := withRequestDecoder.DecodeRequest(&inputs, req)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, "1")
+	assertEqual(t, inputs.B.Val, int64(2))
+	assertEqual(t, inputs.C.D.Val, "3")
+
+	req = httptest.NewRequest("POST", "/", strings.NewReader(`a=%zz`))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	e = withRequestDecoder.DecodeRequest(&inputs, req)
+	assertEqual(t, e, ErrorHash{"error": ErrMalformedBody})
+}
+
+func TestDecodeRequestMultipart(t *testing.T) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("a", "1")
+	w.WriteField("c.d", "3")
+	w.Close()
+
+	req := httptest.NewRequest("POST", "/?b=2", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	var inputs withRequest
+	e := withRequestDecoder.DecodeRequest(&inputs, req)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, "1")
+	assertEqual(t, inputs.B.Val, int64(2))
+	assertEqual(t, inputs.C.D.Val, "3")
+
+	req = httptest.NewRequest("POST", "/", strings.NewReader("not multipart"))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	e = withRequestDecoder.DecodeRequest(&inputs, req)
+	assertEqual(t, e, ErrorHash{"error": ErrMalformedBody})
+}
+
+func TestDecodeRequestQueryOnly(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?a=1&c.d=3", nil)
+
+	var inputs withRequest
+	e := withRequestDecoder.DecodeRequest(&inputs, req)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, "1")
+	assertEqual(t, inputs.C.D.Val, "3")
+}
+
+func TestDecodeRequestUnsupportedMediaType(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", strings.NewReader("a,b\n1,2\n"))
+	req.Header.Set("Content-Type", "text/csv")
+
+	var inputs withRequest
+	e := withRequestDecoder.DecodeRequest(&inputs, req)
+	assertEqual(t, e, ErrorHash{"error": ErrUnsupportedMediaType})
+
+	req = httptest.NewRequest("POST", "/", strings.NewReader(`a=1`))
+	e = withRequestDecoder.DecodeRequest(&inputs, req)
+	assertEqual(t, e, ErrorHash{"error": ErrUnsupportedMediaType})
+}
+
+func TestDecodeRequestMaxBodyBytes(t *testing.T) {
+	d := NewDecoderWithOptions(&withRequest{}, DecoderOptions{MaxBodyBytes: 10})
+
+	for _, contentType := range []string{"application/json", "application/x-www-form-urlencoded"} {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{"a":"`+strings.Repeat("x", 100)+`"}`))
+		req.Header.Set("Content-Type", contentType)
+
+		var inputs withRequest
+		e := d.DecodeRequest(&inputs, req)
+		assertEqual(t, e, ErrorHash{"error": ErrBodyTooLarge})
+	}
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"a":"1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	var inputs withRequest
+	e := d.DecodeRequest(&inputs, req)
+	assertEqual(t, e, ErrorHash(nil))
+}
+
+type withLocations struct {
+	Id             Int64  `meta_in_location:"path" meta_required:"true"`
+	IdempotencyKey String `meta_from:"header" meta_required:"true"`
+	Session        String `meta_from:"cookie"`
+	Name           String
+}
+
+var withLocationsDecoder = NewDecoder(&withLocations{})
+
+func TestDecodeRequestLocations(t *testing.T) {
+	req := httptest.NewRequest("POST", "/users/7", strings.NewReader(`{"name":"bob"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("idempotency-key", "abc")
+	req.Header.Set("Name", "header")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+
+	var inputs withLocations
+	e := withLocationsDecoder.DecodeRequest(&inputs, req, NewPathSource(map[string]string{"id": "7"}))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.Id.Val, int64(7))
+	assertEqual(t, inputs.IdempotencyKey.Val, "abc")
+	assertEqual(t, inputs.Session.Val, "s1")
+	assertEqual(t, inputs.Name.Val, "bob")
+
+	// Located fields aren't read from the body or query.
+	req = httptest.NewRequest("POST", "/?idempotency_key=abc", strings.NewReader(`{"id":7}`))
+	req.Header.Set("Content-Type", "application/json")
+	e = withLocationsDecoder.DecodeRequest(&withLocations{}, req, NewPathSource(map[string]string{"id": "x"}))
+	assertEqual(t, e, ErrorHash{"id": ErrInt, "idempotency_key": ErrRequired})
+}
+
+func TestDecodeRequestLocationsDisallowUnknown(t *testing.T) {
+	d := NewDecoderWithOptions(&withLocations{}, DecoderOptions{DisallowUnknown: true})
+	req := httptest.NewRequest("GET", "/?name=bob", nil)
+	req.Header.Set("Idempotency-Key", "abc")
+	req.Header.Set("User-Agent", "test")
+
+	var inputs withLocations
+	e := d.DecodeRequest(&inputs, req, NewPathSource(map[string]string{"id": "7", "org": "x"}))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.Name.Val, "bob")
+}
+
+func TestDecodeRequestFormBrackets(t *testing.T) {
+	d := NewDecoderWithOptions(&withRequest{}, DecoderOptions{FormBrackets: true})
+	req := httptest.NewRequest("POST", "/?b=2", strings.NewReader(`a=1&c[d]=3`))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var inputs withRequest
+	e := d.DecodeRequest(&inputs, req)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.C.D.Val, "3")
+}
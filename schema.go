@@ -0,0 +1,331 @@
+package meta
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema (draft 2020-12) document. Only the keywords that a Decoder can express are included.
+type Schema struct {
+	Schema      string             `json:"$schema,omitempty"`
+	Ref         string             `json:"$ref,omitempty"`
+	Type        interface{}        `json:"type,omitempty"` // a string, or []string when the value can also be null
+	Description string             `json:"description,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
+	Enum        []interface{}      `json:"enum,omitempty"`
+	Default     interface{}        `json:"default,omitempty"`
+	Minimum     interface{}        `json:"minimum,omitempty"`
+	Maximum     interface{}        `json:"maximum,omitempty"`
+	MinLength   *int               `json:"minLength,omitempty"`
+	MaxLength   *int               `json:"maxLength,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	MinItems    *int               `json:"minItems,omitempty"`
+	MaxItems    *int               `json:"maxItems,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	// AdditionalProperties is only set to false, for decoders with DisallowUnknown.
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// JSONSchema describes the JSON input that d accepts: field names, required fields, docs, and the constraints from each field's options.
+// Structs that contain themselves, directly or through other structs, are put in $defs and referenced with $ref.
+func (d *Decoder) JSONSchema() *Schema {
+	g := &schemaGenerator{
+		defNames:  make(map[*Decoder]string),
+		building:  make(map[*Decoder]bool),
+		recursive: make(map[*Decoder]bool),
+		defs:      make(map[string]*Schema),
+	}
+	schema := g.structSchema(d)
+	if len(g.defs) > 0 {
+		schema.Defs = g.defs
+	}
+	schema.Schema = JSONSchemaDialect
+	return schema
+}
+
+// FormSchema describes the url.Values input that d accepts. Its properties are the dotted keys, eg "c.d" for a nested struct.
+// Slices of values and slices of structs are described once, at index 0 ("a.0", "items.0.name"), and take further indices in order.
+// A key is required if its field, and every struct containing it, is required. Recursive structs are left out.
+func (d *Decoder) FormSchema() *Schema {
+	schema := &Schema{Schema: JSONSchemaDialect, Type: "object", Properties: make(map[string]*Schema)}
+	formSchema(schema, d, "", true, make(map[*Decoder]bool))
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func formSchema(schema *Schema, d *Decoder, prefix string, required bool, building map[*Decoder]bool) {
+	if building[d] {
+		return
+	}
+	building[d] = true
+	defer delete(building, d)
+
+	for _, dfield := range d.Fields {
+		key := joinPath(prefix, dfield.Name)
+		fieldRequired := required && dfield.Required
+		sliceRequired := required && dfield.SliceOptions != nil && dfield.MinLengthPresent && dfield.MinLength > 0
+
+		var fieldSchema *Schema
+		switch dfield.fieldCategory {
+		case categoryValuer:
+			fieldSchema = valueSchema(dfield.Options)
+			if dfield.Default != "" {
+				fieldSchema.Default = schemaDefault(dfield.indirectedType, dfield.Default, dfield.Options)
+			}
+		case categoryStruct:
+			formSchema(schema, dfield.StructDecoder, key, fieldRequired, building)
+			continue
+		case categorySliceOfValues:
+			key = joinPath(key, "0")
+			fieldRequired = sliceRequired
+			fieldSchema = valueSchema(dfield.Options)
+		case categorySliceOfStructs:
+			formSchema(schema, dfield.StructDecoder, joinPath(key, "0"), sliceRequired, building)
+			continue
+		default:
+			continue
+		}
+
+		if dfield.Doc != "" {
+			fieldSchema.Description = dfield.Doc
+		}
+		if dfield.DocPattern != "" && fieldSchema.Pattern == "" && isStringSchema(fieldSchema) {
+			fieldSchema.Pattern = dfield.DocPattern
+		}
+		schema.Properties[key] = fieldSchema
+		if fieldRequired {
+			schema.Required = append(schema.Required, key)
+		}
+	}
+}
+
+type schemaGenerator struct {
+	defNames  map[*Decoder]string
+	building  map[*Decoder]bool // decoders that we're in the middle of generating
+	recursive map[*Decoder]bool // decoders that refer back to themselves
+	defs      map[string]*Schema
+}
+
+func (g *schemaGenerator) defName(d *Decoder) string {
+	if name, ok := g.defNames[d]; ok {
+		return name
+	}
+	base := d.StructType.Name()
+	if base == "" {
+		base = "struct"
+	}
+	name := base
+	for i := 2; g.nameTaken(name); i += 1 {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	g.defNames[d] = name
+	return name
+}
+
+func (g *schemaGenerator) nameTaken(name string) bool {
+	for _, n := range g.defNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *schemaGenerator) structSchema(d *Decoder) *Schema {
+	if g.building[d] {
+		g.recursive[d] = true
+		return &Schema{Ref: "#/$defs/" + g.defName(d)}
+	}
+	g.building[d] = true
+	defer delete(g.building, d)
+
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, dfield := range d.Fields {
+		// Fields that are only read from eg headers aren't part of a JSON body.
+		if len(dfield.From) > 0 && !containsString(dfield.From, SourceBody) {
+			continue
+		}
+
+		var fieldSchema *Schema
+		switch dfield.fieldCategory {
+		case categoryValuer:
+			fieldSchema = valueSchema(dfield.Options)
+			if dfield.Default != "" {
+				fieldSchema.Default = schemaDefault(dfield.indirectedType, dfield.Default, dfield.Options)
+			}
+		case categoryStruct:
+			fieldSchema = g.structSchema(dfield.StructDecoder)
+		case categorySliceOfValues:
+			fieldSchema = &Schema{Type: "array", Items: valueSchema(dfield.Options)}
+			setSliceSchema(fieldSchema, dfield.SliceOptions)
+		case categorySliceOfStructs:
+			fieldSchema = &Schema{Type: "array", Items: g.structSchema(dfield.StructDecoder)}
+			setSliceSchema(fieldSchema, dfield.SliceOptions)
+		default:
+			continue
+		}
+
+		if dfield.Doc != "" {
+			fieldSchema.Description = dfield.Doc
+		}
+		if dfield.DocPattern != "" && fieldSchema.Pattern == "" && isStringSchema(fieldSchema) {
+			fieldSchema.Pattern = dfield.DocPattern
+		}
+		schema.Properties[dfield.Name] = fieldSchema
+		if dfield.Required {
+			schema.Required = append(schema.Required, dfield.Name)
+		}
+	}
+
+	if d.Options.DisallowUnknown && !d.hasCatchAll() {
+		additional := false
+		schema.AdditionalProperties = &additional
+	}
+
+	if g.recursive[d] {
+		g.defs[g.defName(d)] = schema
+		return &Schema{Ref: "#/$defs/" + g.defName(d)}
+	}
+	return schema
+}
+
+// valueSchema describes a single Valuer from its parsed options.
+func valueSchema(options interface{}) *Schema {
+	schema := &Schema{}
+	var null bool
+
+	switch opts := options.(type) {
+	case *StringOptions:
+		schema.Type = "string"
+		null = opts.Null
+		setStringSchema(schema, opts)
+	case *IntOptions:
+		schema.Type = "integer"
+		null = opts.Null
+		if opts.MinPresent {
+			schema.Minimum = opts.Min
+		}
+		if opts.MaxPresent {
+			schema.Maximum = opts.Max
+		}
+		for _, v := range opts.In {
+			schema.Enum = append(schema.Enum, v)
+		}
+	case *UintOptions:
+		schema.Type = "integer"
+		null = opts.Null
+		schema.Minimum = opts.Min
+		if opts.MaxPresent {
+			schema.Maximum = opts.Max
+		}
+		for _, v := range opts.In {
+			schema.Enum = append(schema.Enum, v)
+		}
+	case *FloatOptions:
+		schema.Type = "number"
+		null = opts.Null
+		if opts.MinPresent {
+			schema.Minimum = opts.Min
+		}
+		if opts.MaxPresent {
+			schema.Maximum = opts.Max
+		}
+		for _, v := range opts.In {
+			schema.Enum = append(schema.Enum, v)
+		}
+	case *BoolOptions:
+		schema.Type = "boolean"
+		null = opts.Null
+	case *TimeOptions:
+		schema.Type = "string"
+		null = opts.Null
+		if len(opts.Format) == 1 && opts.Format[0] == time.RFC3339 {
+			schema.Format = "date-time"
+		}
+	case *BytesOptions:
+		schema.Type = "string"
+		schema.Format = "byte"
+		null = opts.Null
+	case *FileOptions:
+		schema.Type = "string"
+		schema.Format = "binary"
+	case *IntSliceOptions:
+		schema.Type = "array"
+		schema.Items = valueSchema(opts.IntOptions)
+		setSliceSchema(schema, opts.SliceOptions)
+	case *StringSliceOptions:
+		schema.Type = "array"
+		schema.Items = valueSchema(opts.StringOptions)
+		setSliceSchema(schema, opts.SliceOptions)
+	default:
+		// Some other Valuer; we don't know what it accepts.
+	}
+
+	if null {
+		schema.Type = []string{schema.Type.(string), "null"}
+	}
+	return schema
+}
+
+func setStringSchema(schema *Schema, opts *StringOptions) {
+	if opts.MinRunesPresent {
+		n := opts.MinRunes
+		schema.MinLength = &n
+	}
+	if opts.MaxRunesPresent {
+		n := opts.MaxRunes
+		schema.MaxLength = &n
+	}
+	if opts.Pattern != nil {
+		schema.Pattern = opts.Pattern.String()
+	}
+	for _, v := range opts.In {
+		schema.Enum = append(schema.Enum, v)
+	}
+}
+
+func setSliceSchema(schema *Schema, opts *SliceOptions) {
+	if opts == nil {
+		return
+	}
+	if opts.MinLengthPresent {
+		n := opts.MinLength
+		schema.MinItems = &n
+	}
+	if opts.MaxLengthPresent {
+		n := opts.MaxLength
+		schema.MaxItems = &n
+	}
+}
+
+func isStringSchema(schema *Schema) bool {
+	switch t := schema.Type.(type) {
+	case string:
+		return t == "string"
+	case []string:
+		return len(t) > 0 && t[0] == "string"
+	}
+	return false
+}
+
+// schemaDefault converts a meta_default tag for a non-string value into the value it decodes to, so it has the right JSON type.
+// Strings are kept as written so a default like "now" for a Time isn't turned into the time the schema was generated.
+func schemaDefault(typ reflect.Type, def string, options interface{}) interface{} {
+	valuer := reflect.New(typ)
+	if err := valuer.Interface().(Valuer).JSONValue("", def, options); err != nil {
+		return def
+	}
+	if v, ok, err := encodeValue(valuer.Elem(), options); err == nil && ok {
+		if _, isString := v.(string); !isString {
+			return v
+		}
+	}
+	return def
+}
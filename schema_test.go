@@ -0,0 +1,67 @@
+package meta
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type withSchema struct {
+	Name   String  `meta_required:"true" meta_min_runes:"1" meta_max_runes:"10" doc:"The name" doc_pattern:"^[a-z]+$"`
+	Kind   String  `meta_in:"a,b"`
+	Age    Int64   `meta_min:"0" meta_max:"150" meta_default:"18"`
+	Score  Float64 `meta_null:"true"`
+	Active Bool
+	At     Time       `meta_format:"2006-01-02"`
+	Ids    Int64Slice `meta_max_length:"3"`
+	Nested *struct {
+		Tags []String `meta_min_length:"1"`
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	schema := NewDecoder(&withSchema{}).JSONSchema()
+	j, err := json.Marshal(schema)
+	assert(t, err == nil)
+	assertEqual(t, string(j), `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{`+
+		`"active":{"type":"boolean"},`+
+		`"age":{"type":"integer","default":18,"minimum":0,"maximum":150},`+
+		`"at":{"type":"string"},`+
+		`"ids":{"type":"array","items":{"type":"integer"},"maxItems":3},`+
+		`"kind":{"type":"string","enum":["a","b"]},`+
+		`"name":{"type":"string","description":"The name","pattern":"^[a-z]+$","minLength":1,"maxLength":10},`+
+		`"nested":{"type":"object","properties":{"tags":{"type":"array","items":{"type":"string"},"minItems":1}}},`+
+		`"score":{"type":["number","null"]}},`+
+		`"required":["name"]}`)
+}
+
+func TestJSONSchemaSelfReference(t *testing.T) {
+	schema := withSelfReferenceDecoder.JSONSchema()
+	j, err := json.Marshal(schema)
+	assert(t, err == nil)
+	assertEqual(t, string(j), `{"$schema":"https://json-schema.org/draft/2020-12/schema","$ref":"#/$defs/WithSelfReference","$defs":{"WithSelfReference":`+
+		`{"type":"object","properties":{"children":{"type":"array","items":{"$ref":"#/$defs/WithSelfReference"}},"name":{"type":"string"}}}}}`)
+}
+
+func TestJSONSchemaDefNames(t *testing.T) {
+	g := &schemaGenerator{defNames: make(map[*Decoder]string)}
+	a := NewDecoder(&struct{ A String }{})
+	b := NewDecoder(&struct{ B String }{})
+	c := NewDecoder(&withMetaName{})
+	assertEqual(t, g.defName(a), "struct")
+	assertEqual(t, g.defName(b), "struct2")
+	assertEqual(t, g.defName(c), "withMetaName")
+	assertEqual(t, g.defName(a), "struct")
+}
+
+func TestFormSchema(t *testing.T) {
+	schema := withSliceOfHashesDecoder.FormSchema()
+	j, err := json.Marshal(schema)
+	assert(t, err == nil)
+	assertEqual(t, string(j), `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{`+
+		`"a.0.a":{"type":"string"},"a.0.b":{"type":"string"},"b.0.z":{"type":"string"}}}`)
+
+	// recursive structs stop at the first level
+	schema = withSelfReferenceDecoder.FormSchema()
+	assertEqual(t, len(schema.Properties), 1)
+	assert(t, schema.Properties["name"] != nil)
+}
@@ -235,3 +235,86 @@ func TestSliceOfHashesLength(t *testing.T) {
 	}`))
 	assertEqual(t, e, ErrorHash{"a": ErrMaxLength})
 }
+
+func TestSliceRepeatedKeys(t *testing.T) {
+	var inputs withSliceString
+	e := withSliceStringDecoder.DecodeValues(&inputs, url.Values{
+		"a": {"x", "y"},
+		"b": {"z"},
+	})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, len(inputs.A), 2)
+	assertEqual(t, len(inputs.B), 1)
+	if len(inputs.A) == 2 && len(inputs.B) == 1 {
+		assertEqual(t, inputs.A[0].Val, "x")
+		assertEqual(t, inputs.A[1].Val, "y")
+		assertEqual(t, inputs.A[1].Path, "a.1")
+		assertEqual(t, inputs.B[0].Val, "z")
+	}
+}
+
+func TestSliceOfHashesRepeatedKeys(t *testing.T) {
+	var inputs withSliceOfHashes
+	e := withSliceOfHashesDecoder.DecodeValues(&inputs, url.Values{
+		"a.a": {"Z", "X"},
+		"a.b": {"Y", "W"},
+		"b.z": {"U"},
+	})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, len(inputs.A), 2)
+	assertEqual(t, len(inputs.B), 1)
+	if len(inputs.A) == 2 && len(inputs.B) == 1 {
+		assertEqual(t, inputs.A[0].A.Val, "Z")
+		assertEqual(t, inputs.A[0].B.Val, "Y")
+		assertEqual(t, inputs.A[1].A.Val, "X")
+		assertEqual(t, inputs.A[1].B.Val, "W")
+		assertEqual(t, inputs.B[0].Z.Val, "U")
+	}
+}
+
+func TestSliceSparseIndices(t *testing.T) {
+	values := url.Values{
+		"a.0":  {"x"},
+		"a.2":  {"y"},
+		"a.10": {"z"},
+	}
+
+	var inputs withSliceString
+	e := withSliceStringDecoder.DecodeValues(&inputs, values)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, len(inputs.A), 1)
+
+	compact := NewDecoderWithOptions(&withSliceString{}, DecoderOptions{SparseIndices: SparseIndicesCompact})
+	inputs = withSliceString{}
+	e = compact.DecodeValues(&inputs, values)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, len(inputs.A), 3)
+	if len(inputs.A) == 3 {
+		assertEqual(t, inputs.A[1].Val, "y")
+		assertEqual(t, inputs.A[2].Val, "z")
+		assertEqual(t, inputs.A[2].Path, "a.10")
+	}
+
+	var hashes withSliceOfHashes
+	e = NewDecoderWithOptions(&withSliceOfHashes{}, DecoderOptions{SparseIndices: SparseIndicesCompact}).DecodeValues(&hashes, url.Values{
+		"a.1.a": {"Z"},
+		"b.3.z": {"U"},
+	})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, len(hashes.A), 1)
+	assertEqual(t, len(hashes.B), 1)
+	if len(hashes.B) == 1 {
+		assertEqual(t, hashes.B[0].Z.Val, "U")
+		assertEqual(t, hashes.B[0].Z.Path, "b.3.z")
+	}
+
+	sparseErr := NewDecoderWithOptions(&withSliceString{}, DecoderOptions{SparseIndices: SparseIndicesError})
+	e = sparseErr.DecodeValues(&withSliceString{}, values)
+	assertEqual(t, e, ErrorHash{"a": ErrSparseIndex})
+	e = sparseErr.DecodeValues(&withSliceString{}, url.Values{"a.1": {"y"}, "a.0": {"x"}})
+	assertEqual(t, e, ErrorHash(nil))
+
+	maxIndex := NewDecoderWithOptions(&withSliceString{}, DecoderOptions{SparseIndices: SparseIndicesCompact, MaxIndex: 5})
+	e = maxIndex.DecodeValues(&withSliceString{}, values)
+	assertEqual(t, e, ErrorHash{"error": ErrMaxIndex})
+}
@@ -2,15 +2,22 @@ package meta
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/textproto"
 	"net/url"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 )
 
-// source normalizes form value and json.
-type source interface {
-	Get(key string) source
-	// A pointer must be passed.
+// Source normalizes an input format (json, form values, ...) into a tree that a Decoder can walk.
+// Implement it to decode other formats with Decoder.DecodeSource.
+type Source interface {
+	// Get returns the child at key. Numeric keys index into arrays. Get must not return nil; return an empty Source instead.
+	Get(key string) Source
+	// A pointer must be passed. Decoders always pass a *interface{}, which should be set to a string, json.Number, bool, nil,
+	// or a map[string]interface{} / []interface{} of those, which is what Valuers expect.
 	// If the value is not present, the pointer will be nil.
 	Value(interface{}) Errorable
 	Empty() bool
@@ -27,18 +34,19 @@ type source interface {
 // merged source
 //
 
-type mergedSource []source
+type mergedSource []Source
 
-func newMergedSource(src ...source) source {
+// NewMergedSource combines sources. Values are taken from the first source that has them.
+func NewMergedSource(src ...Source) Source {
 	return mergedSource(src)
 }
 
-func (s mergedSource) Get(key string) source {
-	var src []source
+func (s mergedSource) Get(key string) Source {
+	var src []Source
 	for _, m := range s {
 		src = append(src, m.Get(key))
 	}
-	return newMergedSource(src...)
+	return NewMergedSource(src...)
 }
 
 func (s mergedSource) Empty() bool {
@@ -87,17 +95,33 @@ func (s mergedSource) ValueMap() map[string]interface{} {
 }
 
 func (s mergedSource) Path() string {
-	if len(s) > 0 {
-		return s[0].Path()
-	} else {
-		return ""
+	// the path of the source that Value uses, or else the first path there is
+	for _, m := range s {
+		if m.Malformed() || !m.Empty() {
+			return m.Path()
+		}
+	}
+	for _, m := range s {
+		if path := m.Path(); path != "" {
+			return path
+		}
 	}
+	return ""
 }
 
-func newJSONSource(b []byte) source {
-	return &jsonSource{
-		RawMessage: b,
+// NewJSONSource parses b once into a tree of map[string]interface{}, []interface{} and json.Number/string/bool/nil values.
+// Get then walks the tree, so decoding a slice of structs doesn't re-parse the same bytes for every field and index.
+func NewJSONSource(b []byte) Source {
+	s := &jsonSource{}
+	if len(b) == 0 {
+		return s
+	}
+	s.present = true
+	// json.Valid rejects trailing data that UnmarshalUsingNumber would ignore.
+	if !json.Valid(b) || MetaJson.UnmarshalUsingNumber(b, &s.value) != nil {
+		s.invalid = true
 	}
+	return s
 }
 
 //
@@ -105,81 +129,73 @@ func newJSONSource(b []byte) source {
 //
 
 type jsonSource struct {
-	json.RawMessage
+	value     interface{}
+	present   bool // false if the key wasn't in the input. A JSON null is present.
+	invalid   bool // the input couldn't be parsed, so every child is malformed
 	malformed bool
 	path      string
 }
 
 func (jv *jsonSource) Empty() bool {
-	return len(jv.RawMessage) == 0
+	return !jv.present
 }
 
 func (jv *jsonSource) Malformed() bool {
 	return jv.malformed
 }
 
-func (jv *jsonSource) Get(key string) source {
-	var path string
-	if jv.path == "" {
-		path = key
-	} else {
-		path = jv.path + "." + key
-	}
+func (jv *jsonSource) Get(key string) Source {
 	s := &jsonSource{
-		malformed: jv.malformed,
-		path:      path,
+		malformed: jv.malformed || jv.invalid,
+		path:      joinPath(jv.path, key),
 	}
-	if len(jv.RawMessage) == 0 {
+	if !jv.present || s.malformed {
 		return s
 	}
 	// numeric key implies array
 	i, err := strconv.Atoi(key)
 	if err == nil {
-		var slice []json.RawMessage
-		err = MetaJson.Unmarshal(jv.RawMessage, &slice)
-		if err != nil {
+		switch value := jv.value.(type) {
+		case []interface{}:
+			if i >= 0 && i < len(value) {
+				s.value = value[i]
+				s.present = true
+			}
+		case nil:
+		default:
 			s.malformed = true
-			return s
-		}
-		if i >= len(slice) {
-			return s
 		}
-		s.RawMessage = slice[i]
 		return s
 	}
-	var m map[string]json.RawMessage
-	err = MetaJson.Unmarshal(jv.RawMessage, &m)
-	if err != nil {
+	switch value := jv.value.(type) {
+	case map[string]interface{}:
+		s.value, s.present = value[key]
+	case nil:
+	default:
 		s.malformed = true
-		return s
 	}
-	raw, ok := m[key]
-	if !ok {
-		return s
-	}
-	s.RawMessage = raw
 	return s
 }
 
 func (jv *jsonSource) Value(i interface{}) Errorable {
-	if len(jv.RawMessage) == 0 {
+	if !jv.present {
 		return ErrBlank
 	}
-
-	err := MetaJson.UnmarshalUsingNumber(jv.RawMessage, i)
-	if err != nil {
+	if jv.invalid {
 		return ErrMalformed
 	}
+
+	switch v := i.(type) {
+	case *interface{}:
+		*v = jv.value
+	default:
+		return ErrBlank
+	}
 	return nil
 }
 
 func (jv *jsonSource) ValueMap() map[string]interface{} {
-	var out map[string]interface{}
-
-	err := MetaJson.UnmarshalUsingNumber(jv.RawMessage, &out)
-	if err != nil {
-		return nil
-	}
+	out, _ := jv.value.(map[string]interface{})
 	return out
 }
 
@@ -187,72 +203,178 @@ func (jv *jsonSource) Path() string {
 	return jv.path
 }
 
+func (jv *jsonSource) tree() (interface{}, bool) {
+	return jv.value, !jv.invalid
+}
+
 //
 // form value source
 //
 
-func newFormValueSource(urlValues url.Values) source {
+// NewFormValueSource reads url.Values with dotted keys, eg "c.d" or "a.0".
+//
+// A key can be repeated for a slice: ids=1&ids=2 is the same as ids.0=1&ids.1=2. For a slice of structs,
+// the nth value of each repeated key goes to the nth struct, so items.name=a&items.name=b&items.qty=1&items.qty=2
+// is two items. How Int64Slice and StringSlice read repeated keys is set by DecoderOptions.FormSlices.
+func NewFormValueSource(urlValues url.Values) Source {
+	root := formValueTree(urlValues)
+	if len(root) > 0 {
+		return &mapSource{value: root, form: true}
+	}
+	return &emptySource{}
+}
+
+// NewBracketFormValueSource is NewFormValueSource for forms with bracket keys, eg user[address][city] or items[0][name].
+// An empty bracket appends, so tags[]=a&tags[]=b is the repeated key tags, and items[][name]=a&items[][name]=b is two items.
+// Dotted keys still work.
+func NewBracketFormValueSource(urlValues url.Values) Source {
+	return NewFormValueSource(bracketValues(urlValues))
+}
+
+// bracketValues rewrites bracket keys as dotted keys. Values of keys that end up the same, eg tags[]=a&tags=b, are merged.
+func bracketValues(urlValues url.Values) url.Values {
+	keys := make([]string, 0, len(urlValues))
+	for key := range urlValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // so merged values are in a predictable order
+
+	values := make(url.Values, len(urlValues))
+	for _, key := range keys {
+		dotted := bracketKey(key)
+		values[dotted] = append(values[dotted], urlValues[key]...)
+	}
+	return values
+}
+
+// bracketKey turns a[b][c] into a.b.c, dropping empty brackets: a[][b] is a.b. Keys that aren't well formed are left alone.
+func bracketKey(key string) string {
+	i := strings.IndexByte(key, '[')
+	if i <= 0 || !strings.HasSuffix(key, "]") {
+		return key
+	}
+
+	parts := []string{key[:i]}
+	for _, part := range strings.Split(key[i+1:len(key)-1], "][") {
+		if strings.ContainsAny(part, "[]") {
+			return key
+		}
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// formValueTree turns dotted keys into nested maps, eg {"a.b": "1"} -> {"a": {"b": "1"}}
+func formValueTree(urlValues url.Values) map[string]interface{} {
 	root := make(map[string]interface{})
 	for key, v := range urlValues {
-		keyParts := strings.Split(key, ".")
-		m := root
-		for i, k := range keyParts {
-			if i == len(keyParts)-1 {
-				if len(v) == 1 {
-					m[k] = v[0]
-				} else {
-					m[k] = v
-				}
-			} else {
-				m2, ok := m[k].(map[string]interface{})
-				if !ok {
-					m2 = make(map[string]interface{})
-					m[k] = m2
-				}
-				m = m2
-			}
+		if len(v) == 1 {
+			setDotted(root, key, v[0])
+		} else {
+			setDotted(root, key, v)
 		}
 	}
-	if len(root) > 0 {
-		return &mapSource{value: root}
+	return root
+}
+
+// setDotted sets value in root at a dotted key, making nested maps as needed.
+func setDotted(root map[string]interface{}, key string, value interface{}) {
+	keyParts := strings.Split(key, ".")
+	m := root
+	for i, k := range keyParts {
+		if i == len(keyParts)-1 {
+			m[k] = value
+		} else {
+			m2, ok := m[k].(map[string]interface{})
+			if !ok {
+				m2 = make(map[string]interface{})
+				m[k] = m2
+			}
+			m = m2
+		}
 	}
-	return &emptySource{}
 }
 
 //
 // map source
 //
 
-func newMapSource(m map[string]interface{}) source {
+// NewMapSource reads a tree of map[string]interface{}, []interface{} and values, like DecodeMap.
+func NewMapSource(m map[string]interface{}) Source {
 	return &mapSource{value: m}
 }
 
 type mapSource struct {
 	value map[string]interface{}
 	path  string
+	form  bool // values are strings and []strings from a form, see NewFormValueSource
 }
 
 func (s *mapSource) Empty() bool {
 	return false
 }
 
-func (s *mapSource) Get(key string) source {
+func (s *mapSource) Get(key string) Source {
+	path := key
+	if s.path != "" {
+		path = s.path + "." + key
+	}
+
 	if v, ok := s.value[key]; ok {
-		path := key
-		if s.path != "" {
-			path = s.path + "." + key
+		switch val := v.(type) {
+		case map[string]interface{}:
+			return &mapSource{value: val, path: path, form: s.form}
+		case []interface{}:
+			return &sliceSource{value: val, path: path}
+		case string:
+			if s.form {
+				return &formValueSource{values: []string{val}, path: path}
+			}
+		case []string:
+			if s.form {
+				return &formValueSource{values: val, path: path}
+			}
 		}
+		return &valueSource{value: v, path: path}
+	}
+
+	if s.form {
+		if index, err := strconv.Atoi(key); err == nil && index >= 0 {
+			if column, ok := formColumn(s.value, index); ok {
+				return &mapSource{value: column, path: path, form: true}
+			}
+		}
+	}
+	return &emptySource{}
+}
 
+// formColumn picks the index-th value of each repeated key in m, so that items.name=a&items.name=b
+// can be read as items.0.name=a&items.1.name=b. A key that isn't repeated is only in the first column.
+func formColumn(m map[string]interface{}, index int) (map[string]interface{}, bool) {
+	column := make(map[string]interface{})
+	for k, v := range m {
 		switch val := v.(type) {
 		case map[string]interface{}:
-			return &mapSource{value: val, path: path}
+			if nested, ok := formColumn(val, index); ok {
+				column[k] = nested
+			}
+		case []string:
+			if index < len(val) {
+				column[k] = val[index]
+			}
 		case []interface{}:
-			return &sliceSource{value: val, path: path}
+			if index < len(val) {
+				column[k] = val[index]
+			}
 		default:
-			return &valueSource{value: v, path: path}
+			if index == 0 {
+				column[k] = v
+			}
 		}
 	}
-	return &emptySource{}
+	return column, len(column) > 0
 }
 
 func (s *mapSource) Malformed() bool {
@@ -291,7 +413,7 @@ func (s *sliceSource) Empty() bool {
 	return len(s.value) == 0
 }
 
-func (s *sliceSource) Get(key string) source {
+func (s *sliceSource) Get(key string) Source {
 	index, err := strconv.Atoi(key)
 	if err != nil {
 		s.malformed = true
@@ -342,6 +464,75 @@ func (s *sliceSource) Path() string {
 	return s.path
 }
 
+//
+// compact source
+//
+
+// compactSource reads a slice with gaps in its indices as if it had none: Get("1") is the second index, whatever it is.
+type compactSource struct {
+	Source
+	indices []int
+}
+
+func (s *compactSource) Get(key string) Source {
+	i, err := strconv.Atoi(key)
+	if err != nil || i < 0 || i >= len(s.indices) {
+		return &emptySource{}
+	}
+	return s.Source.Get(strconv.Itoa(s.indices[i]))
+}
+
+//
+// form value source
+//
+
+// formValueSource is the values of one form key. A single value reads as a string, and repeated values
+// read as a []string, or as a slice with Get. Reading into a *[]string gives the values either way.
+type formValueSource struct {
+	values []string
+	path   string
+}
+
+func (s *formValueSource) Get(key string) Source {
+	index, err := strconv.Atoi(key)
+	if err != nil || index < 0 || index >= len(s.values) {
+		return &emptySource{}
+	}
+	return &valueSource{value: s.values[index], path: s.path + "." + key}
+}
+
+func (s *formValueSource) Value(i interface{}) Errorable {
+	switch v := i.(type) {
+	case *interface{}:
+		if len(s.values) == 1 {
+			*v = s.values[0]
+		} else {
+			*v = s.values
+		}
+	case *[]string:
+		*v = s.values
+	default:
+		return ErrBlank
+	}
+	return nil
+}
+
+func (s *formValueSource) Empty() bool {
+	return false
+}
+
+func (s *formValueSource) ValueMap() map[string]interface{} {
+	return nil
+}
+
+func (s *formValueSource) Malformed() bool {
+	return false
+}
+
+func (s *formValueSource) Path() string {
+	return s.path
+}
+
 //
 // value source
 //
@@ -351,7 +542,7 @@ type valueSource struct {
 	value interface{}
 }
 
-func (s *valueSource) Get(key string) source {
+func (s *valueSource) Get(key string) Source {
 	return &emptySource{}
 }
 
@@ -387,7 +578,7 @@ func (s *valueSource) Path() string {
 
 type emptySource struct{}
 
-func (s *emptySource) Get(key string) source {
+func (s *emptySource) Get(key string) Source {
 	return s
 }
 
@@ -410,3 +601,245 @@ func (s *emptySource) Malformed() bool {
 func (s *emptySource) Path() string {
 	return ""
 }
+
+//
+// named source
+//
+
+const (
+	SourceBody   = "body"
+	SourceQuery  = "query"
+	SourceHeader = "header"
+	SourceCookie = "cookie"
+	SourcePath   = "path"
+)
+
+// builtinSources are the names of the sources that meta creates, which meta_from can always name.
+var builtinSources = []string{SourceBody, SourceQuery, SourceHeader, SourceCookie, SourcePath, SourceEnv, LayerFlags}
+
+// optInSources are only read by fields that name them in meta_from, so that eg a "Name" header can't fill a name field.
+var optInSources = []string{SourceHeader, SourceCookie, SourcePath}
+
+// NamedSource is a Source that knows where it came from, eg SourceQuery.
+// Names are used by DecoderOptions.SourcePrecedence and by the meta_from tag.
+type NamedSource struct {
+	Source
+	Name string
+}
+
+func NewNamedSource(name string, src Source) Source {
+	return &NamedSource{Source: src, Name: name}
+}
+
+func (s *NamedSource) Get(key string) Source {
+	return &NamedSource{Source: s.Source.Get(key), Name: s.Name}
+}
+
+func sourceName(src Source) (string, bool) {
+	if named, ok := src.(*NamedSource); ok {
+		return named.Name, true
+	}
+	return "", false
+}
+
+// filterSources keeps the named sources in src whose name is in names. Unnamed sources are kept, since there's no telling where they came from.
+func filterSources(src Source, names []string) Source {
+	return filterSourcesBy(src, func(name string, named bool) bool {
+		return !named || containsString(names, name)
+	})
+}
+
+// defaultSources drops header, cookie and path sources, which only fill fields that ask for them with meta_from.
+func defaultSources(src Source) Source {
+	return filterSourcesBy(src, func(name string, named bool) bool {
+		return !named || !containsString(optInSources, name)
+	})
+}
+
+func filterSourcesBy(src Source, keep func(name string, named bool) bool) Source {
+	keepSource := func(s Source) bool {
+		name, named := sourceName(s)
+		return keep(name, named)
+	}
+
+	if merged, ok := src.(mergedSource); ok {
+		var kept []Source
+		for _, s := range merged {
+			if keepSource(s) {
+				kept = append(kept, s)
+			}
+		}
+		if len(kept) == len(merged) {
+			return src
+		}
+		return NewMergedSource(kept...)
+	}
+	if keepSource(src) {
+		return src
+	}
+	return &emptySource{}
+}
+
+// orderSources sorts merged sources by their position in precedence. Sources that aren't in precedence keep their order, after those that are.
+func orderSources(src Source, precedence []string) Source {
+	merged, ok := src.(mergedSource)
+	if !ok {
+		return src
+	}
+	rank := func(s Source) int {
+		if name, ok := sourceName(s); ok {
+			for i, p := range precedence {
+				if p == name {
+					return i
+				}
+			}
+		}
+		return len(precedence)
+	}
+	ordered := make(mergedSource, len(merged))
+	copy(ordered, merged)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return rank(ordered[i]) < rank(ordered[j])
+	})
+	return ordered
+}
+
+// sourceValue reads src's value for a field with options. For the types that read form values with FormSlices,
+// eg StringSlice, a form value is read as its []string, so that a form's tags=a,b isn't mistaken for a JSON "a,b".
+func sourceValue(src Source, options interface{}) (interface{}, Errorable) {
+	switch options.(type) {
+	case *IntSliceOptions, *StringSliceOptions:
+		var values []string
+		if src.Value(&values) == nil {
+			return values, nil
+		}
+	}
+	var val interface{}
+	err := src.Value(&val)
+	return val, err
+}
+
+// conflicting reports whether more than one of the merged sources in src has a value, and they're different.
+// Values are compared after decoding them into a new typ with options, so that a JSON 1.0 and a form "1" agree
+// for a Float64, as do a JSON [1,2] and a form "1,2" for an Int64Slice. A value that doesn't decode only agrees
+// with another that doesn't.
+func conflicting(src Source, typ reflect.Type, options interface{}) bool {
+	merged, ok := src.(mergedSource)
+	if !ok {
+		return false
+	}
+	var first interface{}
+	found := false
+	for _, s := range merged {
+		if s.Empty() || s.Malformed() {
+			continue
+		}
+		val, err := sourceValue(s, options)
+		if err != nil {
+			continue
+		}
+		decoded := reflect.New(typ)
+		var result interface{}
+		if decoded.Interface().(Valuer).JSONValue("", val, options) == nil {
+			result = decoded.Elem().Interface()
+		}
+		if found && !equalValues(result, first) {
+			return true
+		}
+		first, found = result, true
+	}
+	return false
+}
+
+// valueEqualer is a value type that compares its values itself, eg Time, where the same instant can be written in different zones.
+type valueEqualer interface {
+	equalValue(other interface{}) bool
+}
+
+func equalValues(a, b interface{}) bool {
+	if e, ok := a.(valueEqualer); ok {
+		return e.equalValue(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func containsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
+
+//
+// header, cookie and path source
+//
+
+// NewHeaderSource reads request headers, keyed by HeaderKey. If a header is repeated, the first value is used. It's named SourceHeader.
+func NewHeaderSource(h http.Header) Source {
+	values := make(map[string]string, len(h))
+	for k := range h {
+		values[textproto.CanonicalMIMEHeaderKey(k)] = h.Get(k)
+	}
+	return NewNamedSource(SourceHeader, &flatSource{values: values, mapKey: HeaderKey})
+}
+
+// NewCookieSource reads cookies by name. If a name is repeated, the first cookie is used. It's named SourceCookie.
+func NewCookieSource(cookies []*http.Cookie) Source {
+	values := make(map[string]string, len(cookies))
+	for _, c := range cookies {
+		if _, ok := values[c.Name]; !ok {
+			values[c.Name] = c.Value
+		}
+	}
+	return NewNamedSource(SourceCookie, &flatSource{values: values})
+}
+
+// NewPathSource reads path parameters from a router, eg {"id": "123"} for /users/{id}. It's named SourcePath.
+func NewPathSource(params map[string]string) Source {
+	return NewNamedSource(SourcePath, &flatSource{values: params})
+}
+
+// HeaderKey is the header that a field named key reads: underscores become dashes and it's canonicalized,
+// so a field named IdempotencyKey (idempotency_key) reads the Idempotency-Key header.
+func HeaderKey(key string) string {
+	return textproto.CanonicalMIMEHeaderKey(strings.Replace(key, "_", "-", -1))
+}
+
+// flatSource is a single level of string values. Its keys aren't input fields, so ValueMap is always nil:
+// they're never unknown fields and never end up in a meta:"*" map.
+type flatSource struct {
+	values map[string]string
+	mapKey func(string) string
+}
+
+func (s *flatSource) Get(key string) Source {
+	if s.mapKey != nil {
+		key = s.mapKey(key)
+	}
+	if v, ok := s.values[key]; ok {
+		return &valueSource{value: v, path: key}
+	}
+	return &emptySource{}
+}
+
+func (s *flatSource) Value(i interface{}) Errorable {
+	return ErrBlank
+}
+
+func (s *flatSource) Empty() bool {
+	return len(s.values) == 0
+}
+
+func (s *flatSource) ValueMap() map[string]interface{} {
+	return nil
+}
+
+func (s *flatSource) Malformed() bool {
+	return false
+}
+
+func (s *flatSource) Path() string {
+	return ""
+}
@@ -0,0 +1,384 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// rawJSONSource is the previous json source, which re-unmarshals its RawMessage on every Get.
+// It's kept here to check that jsonSource behaves the same way, and to benchmark against.
+type rawJSONSource struct {
+	json.RawMessage
+	malformed bool
+	path      string
+}
+
+func newRawJSONSource(b []byte) Source {
+	return &rawJSONSource{RawMessage: b}
+}
+
+func (jv *rawJSONSource) Empty() bool {
+	return len(jv.RawMessage) == 0
+}
+
+func (jv *rawJSONSource) Malformed() bool {
+	return jv.malformed
+}
+
+func (jv *rawJSONSource) Get(key string) Source {
+	s := &rawJSONSource{
+		malformed: jv.malformed,
+		path:      joinPath(jv.path, key),
+	}
+	if len(jv.RawMessage) == 0 {
+		return s
+	}
+	i, err := strconv.Atoi(key)
+	if err == nil {
+		var slice []json.RawMessage
+		err = MetaJson.Unmarshal(jv.RawMessage, &slice)
+		if err != nil {
+			s.malformed = true
+			return s
+		}
+		if i >= len(slice) {
+			return s
+		}
+		s.RawMessage = slice[i]
+		return s
+	}
+	var m map[string]json.RawMessage
+	err = MetaJson.Unmarshal(jv.RawMessage, &m)
+	if err != nil {
+		s.malformed = true
+		return s
+	}
+	raw, ok := m[key]
+	if !ok {
+		return s
+	}
+	s.RawMessage = raw
+	return s
+}
+
+func (jv *rawJSONSource) Value(i interface{}) Errorable {
+	if len(jv.RawMessage) == 0 {
+		return ErrBlank
+	}
+	if err := MetaJson.UnmarshalUsingNumber(jv.RawMessage, i); err != nil {
+		return ErrMalformed
+	}
+	return nil
+}
+
+func (jv *rawJSONSource) ValueMap() map[string]interface{} {
+	var out map[string]interface{}
+	if err := MetaJson.UnmarshalUsingNumber(jv.RawMessage, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+func (jv *rawJSONSource) Path() string {
+	return jv.path
+}
+
+func TestJSONSourceMatchesRawJSONSource(t *testing.T) {
+	inputs := []string{
+		``,
+		`null`,
+		`{}`,
+		`[]`,
+		`"a"`,
+		`{"a":1,"b":"x","c":null,"d":[1,{"e":true}],"f":{"g":[]},"0":2}`,
+		`{"a":1,"a":2}`,
+		`[{"a":1},null,[2]]`,
+		`{"a":1`,
+		`{"a":1} {"b":2}`,
+	}
+	paths := [][]string{
+		{"a"}, {"b"}, {"c"}, {"d"}, {"0"}, {"1"}, {"2"},
+		{"a", "b"}, {"a", "0"}, {"b", "0"}, {"c", "x"}, {"c", "0"},
+		{"d", "0"}, {"d", "1", "e"}, {"d", "2"}, {"d", "x"},
+		{"f", "g"}, {"f", "g", "0"}, {"0", "a"}, {"1", "a"}, {"2", "0"},
+	}
+
+	for _, input := range inputs {
+		for _, path := range paths {
+			var got, want Source = NewJSONSource([]byte(input)), newRawJSONSource([]byte(input))
+			for _, key := range path {
+				got, want = got.Get(key), want.Get(key)
+			}
+			where := fmt.Sprintf("%s at %s", input, strings.Join(path, "."))
+
+			if got.Empty() != want.Empty() || got.Malformed() != want.Malformed() || got.Path() != want.Path() {
+				t.Errorf("%s: empty/malformed/path %v/%v/%s, want %v/%v/%s", where, got.Empty(), got.Malformed(), got.Path(), want.Empty(), want.Malformed(), want.Path())
+				continue
+			}
+			if want.Malformed() {
+				continue
+			}
+
+			var gotValue, wantValue interface{}
+			gotErr, wantErr := got.Value(&gotValue), want.Value(&wantValue)
+			if gotErr != wantErr || !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("%s: value %v (%v), want %v (%v)", where, gotValue, gotErr, wantValue, wantErr)
+			}
+			if !reflect.DeepEqual(got.ValueMap(), want.ValueMap()) {
+				t.Errorf("%s: value map %v, want %v", where, got.ValueMap(), want.ValueMap())
+			}
+		}
+	}
+}
+
+type benchmarkItem struct {
+	Name  String `meta_required:"true"`
+	Count Int64
+	Price Float64
+	Tags  []String
+	Owner struct {
+		Email String
+		Admin Bool
+	}
+}
+
+type benchmarkPayload struct {
+	Items []benchmarkItem
+}
+
+var benchmarkDecoder = NewDecoder(&benchmarkPayload{})
+
+func benchmarkJSON(n int) []byte {
+	items := make([]string, n)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"name":"item %d","count":%d,"price":%d.5,"tags":["a","b","c"],"owner":{"email":"user%d@example.com","admin":true}}`, i, i, i, i)
+	}
+	return []byte(`{"items":[` + strings.Join(items, ",") + `]}`)
+}
+
+func benchmarkSource(b *testing.B, n int, newSource func([]byte) Source) {
+	body := benchmarkJSON(n)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var dest benchmarkPayload
+		if errs := benchmarkDecoder.decodeSource(reflect.ValueOf(&dest), newSource(body)); errs != nil {
+			b.Fatal(errs)
+		}
+	}
+}
+
+func BenchmarkJSONSource10(b *testing.B)      { benchmarkSource(b, 10, NewJSONSource) }
+func BenchmarkJSONSource100(b *testing.B)     { benchmarkSource(b, 100, NewJSONSource) }
+func BenchmarkJSONSource1000(b *testing.B)    { benchmarkSource(b, 1000, NewJSONSource) }
+func BenchmarkRawJSONSource10(b *testing.B)   { benchmarkSource(b, 10, newRawJSONSource) }
+func BenchmarkRawJSONSource100(b *testing.B)  { benchmarkSource(b, 100, newRawJSONSource) }
+func BenchmarkRawJSONSource1000(b *testing.B) { benchmarkSource(b, 1000, newRawJSONSource) }
+
+// envLikeSource is a Source for a flat map[string]string with dotted keys, like an application might write.
+type envLikeSource struct {
+	values map[string]string
+	path   string
+}
+
+func (s *envLikeSource) Get(key string) Source {
+	return &envLikeSource{values: s.values, path: joinPath(s.path, key)}
+}
+
+func (s *envLikeSource) Value(i interface{}) Errorable {
+	v, ok := s.values[s.path]
+	if !ok {
+		return ErrBlank
+	}
+	*(i.(*interface{})) = v
+	return nil
+}
+
+func (s *envLikeSource) Empty() bool {
+	for k := range s.values {
+		if k == s.path || strings.HasPrefix(k, s.path+".") {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *envLikeSource) ValueMap() map[string]interface{} { return nil }
+func (s *envLikeSource) Malformed() bool                  { return false }
+func (s *envLikeSource) Path() string                     { return s.path }
+
+func TestDecodeSource(t *testing.T) {
+	var inputs nested
+	e := withNestedDecoder.DecodeSource(&inputs, &envLikeSource{values: map[string]string{"a": "1", "c.d": "2"}})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, "1")
+	assertEqual(t, inputs.C.D.Val, "2")
+	assertEqual(t, inputs.C.D.Path, "c.d")
+
+	// multiple sources are merged
+	inputs = nested{}
+	e = withNestedDecoder.DecodeSource(&inputs, NewJSONSource([]byte(`{"a":"x"}`)), &envLikeSource{values: map[string]string{"a": "1", "c.d": "2"}})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, "x")
+	assertEqual(t, inputs.C.D.Val, "2")
+
+	inputs = nested{}
+	e = withNestedDecoder.DecodeSource(&inputs, NewMapSource(map[string]interface{}{"b": "1"}))
+	assertEqual(t, e, ErrorHash{"a": ErrRequired, "c": ErrRequired})
+}
+
+func TestSourcePrecedence(t *testing.T) {
+	values := url.Values{"a": {"query"}}
+	body := []byte(`{"a":"body"}`)
+
+	var inputs withString
+	e := withStringDecoder.Decode(&inputs, values, body)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, "body")
+
+	d := NewDecoderWithOptions(&withString{}, DecoderOptions{SourcePrecedence: []string{SourceQuery, SourceBody}})
+	e = d.Decode(&inputs, values, body)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, "query")
+
+	// values from a later source keep their paths
+	var nestedInputs nested
+	d = NewDecoderWithOptions(&nested{}, DecoderOptions{SourcePrecedence: []string{SourceQuery, SourceBody}})
+	e = d.Decode(&nestedInputs, url.Values{}, []byte(`{"a":"b","c":{"d":"e"}}`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, nestedInputs.A.Path, "a")
+	assertEqual(t, nestedInputs.C.D.Path, "c.d")
+}
+
+func TestMetaFrom(t *testing.T) {
+	var inputs struct {
+		A String `meta_from:"query"`
+		B String `meta_from:"body"`
+		C struct {
+			D String
+		} `meta_from:"body"`
+		E String `meta_from:"query,body"`
+	}
+	d := NewDecoder(&inputs)
+
+	e := d.Decode(&inputs, url.Values{"a": {"1"}, "b": {"2"}, "c.d": {"3"}, "e": {"4"}}, []byte(`{"a":"5"}`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, "1")
+	assertEqual(t, inputs.B.Present, false)
+	assertEqual(t, inputs.C.D.Present, false)
+	assertEqual(t, inputs.E.Val, "4")
+
+	e = d.Decode(&inputs, nil, []byte(`{"b":"6","c":{"d":"7"}}`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.B.Val, "6")
+	assertEqual(t, inputs.C.D.Val, "7")
+
+	// sources without names aren't filtered
+	inputs.A = String{}
+	e = d.DecodeMap(&inputs, map[string]interface{}{"a": "8"})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, "8")
+}
+
+func TestDetectConflicts(t *testing.T) {
+	d := NewDecoderWithOptions(&withSliceString{}, DecoderOptions{DetectConflicts: true})
+
+	var inputs withSliceString
+	e := d.Decode(&inputs, url.Values{"a.0": {"1"}, "a.1": {"2"}}, []byte(`{"a":["1","3"]}`))
+	assertEqual(t, e, ErrorHash{"a": ErrorSlice{nil, ErrConflict}})
+
+	d = NewDecoderWithOptions(&withInt{}, DecoderOptions{DetectConflicts: true})
+	var ints withInt
+	e = d.Decode(&ints, url.Values{"a": {"1"}, "b": {"2"}}, []byte(`{"a":1}`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, ints.A.Val, int64(1))
+
+	e = d.Decode(&ints, url.Values{"a": {"1"}, "b": {"2"}}, []byte(`{"a":2}`))
+	assertEqual(t, e, ErrorHash{"a": ErrConflict})
+
+	// Equal values written differently don't conflict.
+	d = NewDecoderWithOptions(&withFloat{}, DecoderOptions{DetectConflicts: true})
+	var floats withFloat
+	e = d.Decode(&floats, url.Values{"a": {"1"}, "b": {"2.50"}}, []byte(`{"a":1.0,"b":2.5}`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, floats.A.Val, 1.0)
+
+	d = NewDecoderWithOptions(&withIntSlice{}, DecoderOptions{DetectConflicts: true})
+	var slices withIntSlice
+	e = d.Decode(&slices, url.Values{"a": {"1,2"}}, []byte(`{"a":[1,2]}`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, slices.A.Val, []int64{1, 2})
+
+	e = d.Decode(&slices, url.Values{"a": {"1,3"}}, []byte(`{"a":[1,2]}`))
+	assertEqual(t, e, ErrorHash{"a": ErrConflict})
+
+	d = NewDecoderWithOptions(&withTime{}, DecoderOptions{DetectConflicts: true})
+	var times withTime
+	e = d.Decode(&times, url.Values{"a": {"2015-01-02T03:04:05+00:00"}}, []byte(`{"a":"2015-01-02T03:04:05Z"}`))
+	assertEqual(t, e, ErrorHash(nil))
+
+	e = d.Decode(&times, url.Values{"a": {"2015-01-02T03:04:05+01:00"}}, []byte(`{"a":"2015-01-02T03:04:05Z"}`))
+	assertEqual(t, e, ErrorHash{"a": ErrConflict})
+}
+
+func TestBracketKey(t *testing.T) {
+	assertEqual(t, bracketKey("user[address][city]"), "user.address.city")
+	assertEqual(t, bracketKey("items[0][name]"), "items.0.name")
+	assertEqual(t, bracketKey("tags[]"), "tags")
+	assertEqual(t, bracketKey("items[][name]"), "items.name")
+	assertEqual(t, bracketKey("a.b"), "a.b")
+	assertEqual(t, bracketKey("[a]"), "[a]")
+	assertEqual(t, bracketKey("a[b"), "a[b")
+	assertEqual(t, bracketKey("a[b]c]"), "a[b]c]")
+}
+
+type withBrackets struct {
+	User struct {
+		Address struct {
+			City String
+		}
+	}
+	Tags  StringSlice
+	Ids   []Int64
+	Items []struct {
+		Name String
+	}
+}
+
+func TestFormBrackets(t *testing.T) {
+	d := NewDecoderWithOptions(&withBrackets{}, DecoderOptions{FormBrackets: true})
+	var inputs withBrackets
+	e := d.DecodeValues(&inputs, url.Values{
+		"user[address][city]": {"Paris"},
+		"tags[]":              {"a", "b"},
+		"ids[]":               {"1", "2"},
+		"items[][name]":       {"x", "y"},
+	})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.User.Address.City.Val, "Paris")
+	assertEqual(t, inputs.Tags.Val, []string{"a", "b"})
+	assertEqual(t, len(inputs.Ids), 2)
+	assertEqual(t, len(inputs.Items), 2)
+	if len(inputs.Items) == 2 {
+		assertEqual(t, inputs.Items[1].Name.Val, "y")
+		assertEqual(t, inputs.Items[1].Name.Path, "items.1.name")
+	}
+
+	inputs = withBrackets{}
+	e = d.DecodeValues(&inputs, url.Values{"items[0][name]": {"x"}, "items[1][name]": {"y"}, "user.address.city": {"Paris"}})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, len(inputs.Items), 2)
+	assertEqual(t, inputs.User.Address.City.Val, "Paris")
+
+	// Off by default
+	inputs = withBrackets{}
+	e = NewDecoder(&withBrackets{}).DecodeValues(&inputs, url.Values{"user[address][city]": {"Paris"}})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.User.Address.City.Val, "")
+}
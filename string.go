@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 )
 
@@ -34,6 +36,7 @@ type StringOptions struct {
 	MaxBytesPresent bool
 	MaxBytes        int
 	In              []string
+	Pattern         *regexp.Regexp
 }
 
 func NewString(s string) String {
@@ -112,6 +115,10 @@ func (s *String) ParseOptions(tag reflect.StructTag) interface{} {
 		}
 	}
 
+	if pattern := tag.Get("meta_pattern"); pattern != "" {
+		opts.Pattern = regexp.MustCompile(pattern)
+	}
+
 	return opts
 }
 
@@ -132,7 +139,7 @@ func (s *String) JSONValue(path string, i interface{}, options interface{}) Erro
 		return s.FormValue(value, options)
 	case bool:
 		return s.FormValue(fmt.Sprint(i), options)
-	case json.Number:
+	case json.Number, int64, uint64, float64:
 		return s.FormValue(fmt.Sprint(i), options)
 	}
 	return ErrString
@@ -205,6 +212,11 @@ func (s *String) FormValue(value string, options interface{}) Errorable {
 		}
 	}
 
+	// pattern
+	if opts.Pattern != nil && !opts.Pattern.MatchString(value) {
+		return ErrPattern
+	}
+
 	// success
 	s.Val = value
 	s.Present = true
@@ -219,9 +231,67 @@ func (s String) Value() (driver.Value, error) {
 	return nil, nil
 }
 
+// Scan implements sql.Scanner. A NULL column sets Null.
+func (s *String) Scan(src interface{}) error {
+	s.Val, s.Null, s.Present = "", false, true
+	switch value := src.(type) {
+	case nil:
+		s.Null = true
+	case string:
+		s.Val = value
+	case []byte:
+		s.Val = string(value)
+	case time.Time:
+		s.Val = value.Format(time.RFC3339Nano)
+	case int64, float64, bool:
+		s.Val = fmt.Sprint(value)
+	default:
+		s.Present = false
+		return fmt.Errorf("meta: cannot scan %T into String", src)
+	}
+	return nil
+}
+
 func (s String) MarshalJSON() ([]byte, error) {
 	if s.Present && !s.Null {
 		return MetaJson.Marshal(s.Val)
 	}
 	return nullString, nil
 }
+
+// UnmarshalJSON implements json.Unmarshaler using the default options. null sets Null, so values round trip through MarshalJSON.
+func (s *String) UnmarshalJSON(data []byte) error {
+	*s = String{}
+	if isJSONNull(data) {
+		s.Present = true
+		s.Null = true
+		return nil
+	}
+	opts := s.ParseOptions("").(*StringOptions)
+	opts.Blank = true // so that "" round trips
+	return unmarshalJSONValue(s, data, opts)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using the default options.
+func (s *String) UnmarshalText(text []byte) error {
+	*s = String{}
+	opts := s.ParseOptions("").(*StringOptions)
+	opts.Blank = true // so that "" round trips
+	if err := s.FormValue(string(text), opts); err != nil {
+		return err
+	}
+	return nil
+}
+
+// String implements flag.Value.
+func (s *String) String() string {
+	if !s.Present || s.Null {
+		return ""
+	}
+	return s.Val
+}
+
+// Set implements flag.Value using the default options.
+func (s *String) Set(value string) error {
+	return s.UnmarshalText([]byte(value))
+}
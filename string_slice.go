@@ -40,6 +40,8 @@ func (n *StringSlice) JSONValue(path string, i interface{}, options interface{})
 		return ErrBlank
 	}
 
+	i = options.(*StringSliceOptions).SliceOptions.formValue(i)
+
 	var errorsInSlice ErrorSlice
 	switch value := i.(type) {
 	case string:
@@ -138,3 +140,40 @@ func (s StringSlice) MarshalJSON() ([]byte, error) {
 	}
 	return nullString, nil
 }
+
+// UnmarshalJSON implements json.Unmarshaler using the default options. null and [] leave Val empty.
+func (s *StringSlice) UnmarshalJSON(data []byte) error {
+	*s = StringSlice{}
+	if isJSONNull(data) {
+		return nil
+	}
+	err := unmarshalJSONValue(s, data, s.ParseOptions(""))
+	if err == ErrBlank {
+		return nil
+	}
+	return err
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler for comma separated values using the default options.
+func (s *StringSlice) UnmarshalText(text []byte) error {
+	*s = StringSlice{}
+	if err := s.FormValue(string(text), s.ParseOptions("")); err != nil && err != ErrBlank {
+		return err
+	}
+	return nil
+}
+
+// String implements flag.Value.
+func (s *StringSlice) String() string {
+	return strings.Join(s.Val, ",")
+}
+
+// Set implements flag.Value. Each value is comma separated and added to Val, so the flag can be repeated: -tags=a,b -tags=c.
+func (s *StringSlice) Set(value string) error {
+	var more StringSlice
+	if err := more.UnmarshalText([]byte(value)); err != nil {
+		return err
+	}
+	s.Val = append(s.Val, more.Val...)
+	return nil
+}
@@ -174,3 +174,46 @@ func TestStringSliceLength(t *testing.T) {
 	assertEqual(t, len(inputs.A.Val), 0)
 
 }
+
+func TestStringSlicePattern(t *testing.T) {
+	var inputs struct {
+		A StringSlice `meta_pattern:"^[a-z]+$"`
+	}
+	d := NewDecoder(&inputs)
+
+	e := d.DecodeValues(&inputs, url.Values{"a": {"abc,def"}})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, []string{"abc", "def"})
+
+	e = d.DecodeJSON(&inputs, []byte(`{"a":["abc","d3f"]}`))
+	assertEqual(t, e, ErrorHash{"a": ErrorSlice{nil, ErrPattern}})
+}
+
+func TestStringSliceRepeatedKeys(t *testing.T) {
+	var inputs withStringSlice
+	e := withStringSliceDecoder.DecodeValues(&inputs, url.Values{"a": {"a,b", "c"}})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, []string{"a", "b", "c"})
+
+	repeat := NewDecoderWithOptions(&withStringSlice{}, DecoderOptions{FormSlices: FormSlicesRepeat})
+	e = repeat.DecodeValues(&inputs, url.Values{"a": {"a,b", "c"}})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, []string{"a,b", "c"})
+
+	e = repeat.DecodeValues(&inputs, url.Values{"a": {"a,b"}})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, []string{"a,b"})
+
+	// JSON isn't affected
+	e = repeat.DecodeJSON(&inputs, []byte(`{"a":["a,b","c"]}`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, []string{"a,b", "c"})
+
+	e = repeat.DecodeJSON(&inputs, []byte(`{"a":"a,b"}`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, []string{"a", "b"})
+
+	e = repeat.Decode(&inputs, url.Values{"a": {"a,b"}}, []byte(`{}`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, []string{"a,b"})
+}
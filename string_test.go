@@ -571,3 +571,59 @@ func TestOptionalNullBlankStringOmitted(t *testing.T) {
 	assertEqual(t, inputs.A.Null, false)
 	assertEqual(t, inputs.A.Val, "")
 }
+
+func TestStringPattern(t *testing.T) {
+	var inputs struct {
+		A String `meta_pattern:"^[a-z]+$"`
+	}
+	d := NewDecoder(&inputs)
+
+	e := d.DecodeValues(&inputs, url.Values{"a": {" abc "}})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, "abc")
+
+	e = d.DecodeJSON(&inputs, []byte(`{"a":"ab1"}`))
+	assertEqual(t, e, ErrorHash{"a": ErrPattern})
+
+	e = d.DecodeJSON(&inputs, []byte(`{"a":""}`))
+	assertEqual(t, e, ErrorHash(nil))
+}
+
+func TestStringDocPattern(t *testing.T) {
+	var inputs struct {
+		A String   `doc_pattern:"^[0-9]+$"`
+		B String   `doc_pattern:"^[0-9]+$" meta_pattern:"^[a-z]+$"`
+		C []String `doc_pattern:"^[0-9]+$"`
+	}
+
+	// doc_pattern is only documentation by default
+	e := NewDecoder(&inputs).DecodeJSON(&inputs, []byte(`{"a":"abc","b":"abc","c":["abc"]}`))
+	assertEqual(t, e, ErrorHash(nil))
+
+	d := NewDecoderWithOptions(&inputs, DecoderOptions{EnforceDocPattern: true})
+	e = d.DecodeJSON(&inputs, []byte(`{"a":"abc","b":"abc","c":["123","abc"]}`))
+	assertEqual(t, e, ErrorHash{"a": ErrPattern, "c": ErrorSlice{nil, ErrPattern}})
+
+	e = d.DecodeJSON(&inputs, []byte(`{"a":"123","b":"123"}`))
+	assertEqual(t, e, ErrorHash{"b": ErrPattern})
+}
+
+func TestStringPatternMalformed(t *testing.T) {
+	var inputs struct {
+		A String `meta_pattern:"(abc"`
+	}
+
+	_, err := NewDecoderE(&inputs, DecoderOptions{})
+	assert(t, err != nil)
+}
+
+func TestStringFlagValue(t *testing.T) {
+	s := String{Val: "stale"}
+	assertEqual(t, s.String(), "")
+
+	s = String{Val: "stale", Nullity: Nullity{true}, Presence: Presence{true}}
+	assertEqual(t, s.String(), "")
+
+	assertEqual(t, s.Set("abc"), nil)
+	assertEqual(t, s.String(), "abc")
+}
@@ -0,0 +1,333 @@
+package meta
+
+import (
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"math"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DecodeStruct decodes another struct, eg a database row or a type from another package, into dest,
+// converting and validating it like any other input. src is read as NewStructSource reads it.
+func (d *Decoder) DecodeStruct(dest interface{}, src interface{}) ErrorHash {
+	tree, err := structTree(src)
+	if err != nil {
+		return ErrorHash{"error": ErrMalformed}
+	}
+	return d.DecodeMap(dest, tree)
+}
+
+// NewStructSource reads a struct, or a pointer to one, with reflection. Its fields are named like encoding/json names
+// them, except that a meta tag comes before a json tag and fields without either go through NameMapping, so a struct
+// can be read into a meta struct with the same field names. Embedded structs' fields are promoted, unexported fields
+// are skipped and omitempty is honoured.
+//
+// Values are passed to the fields as they are, much like DecodeMap: time.Times, []bytes and numbers don't go through
+// strings. A driver.Valuer, eg sql.NullString, gives its Value and an encoding.TextMarshaler gives its text. meta's own
+// types give their Val, or nothing if they aren't Present or are a nil pointer. A cycle of pointers, maps or slices,
+// or a src that isn't a struct or map, is malformed.
+func NewStructSource(src interface{}) Source {
+	tree, err := structTree(src)
+	if err != nil {
+		return &jsonSource{present: true, invalid: true}
+	}
+	return NewMapSource(tree)
+}
+
+type structCycleError string
+
+func (e structCycleError) Error() string {
+	return "meta: cycle at " + string(e)
+}
+
+type structTypeError string
+
+func (e structTypeError) Error() string {
+	return "meta: a struct source needs a struct or map, not " + string(e)
+}
+
+// structTree converts src into the tree that DecodeMap takes.
+func structTree(src interface{}) (map[string]interface{}, error) {
+	r := &structReader{seen: make(map[structRef]bool)}
+	value, _, err := r.value(reflect.ValueOf(src), "")
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return map[string]interface{}{}, nil
+	}
+	tree, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, structTypeError(reflect.TypeOf(src).String())
+	}
+	return tree, nil
+}
+
+type structReader struct {
+	seen map[structRef]bool // pointers, maps and slices on the way to the current value, to find cycles
+}
+
+// structRef identifies a pointer, map or slice. A slice's length is part of it, since a shorter slice of the same array
+// isn't a cycle, like encoding/json's ptrSeen.
+type structRef struct {
+	ptr uintptr
+	len int
+}
+
+func newStructRef(v reflect.Value) structRef {
+	ref := structRef{ptr: v.Pointer()}
+	if v.Kind() == reflect.Slice {
+		ref.len = v.Len()
+	}
+	return ref
+}
+
+// enter marks v as on the way to the current value. It's a cycle if it already is; otherwise leave must be called.
+func (r *structReader) enter(v reflect.Value, path string) error {
+	ref := newStructRef(v)
+	if r.seen[ref] {
+		return structCycleError(path)
+	}
+	r.seen[ref] = true
+	return nil
+}
+
+func (r *structReader) leave(v reflect.Value) {
+	delete(r.seen, newStructRef(v))
+}
+
+var (
+	reflectTypeTime       = reflect.TypeOf(time.Time{})
+	reflectTypeFileHeader = reflect.TypeOf(&multipart.FileHeader{})
+)
+
+// value converts v. ok is false if v should be left out, eg a meta value that isn't present or a func.
+func (r *structReader) value(v reflect.Value, path string) (out interface{}, ok bool, err error) {
+	if !v.IsValid() {
+		return nil, true, nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			// A nil pointer to a meta value isn't present, like the value itself.
+			return nil, !(v.Kind() == reflect.Ptr && isMetaValue(v.Type().Elem())), nil
+		}
+	}
+	if v.Kind() == reflect.Ptr && isMetaValue(v.Type().Elem()) {
+		// Read through the pointer, rather than with the pointer's driver.Valuer, so Presence is checked.
+		return r.value(v.Elem(), path)
+	}
+
+	if v.CanInterface() {
+		if v.Type() == reflectTypeTime || v.Type() == reflectTypeFileHeader {
+			return v.Interface(), true, nil
+		}
+		if isMetaValue(v.Type()) {
+			if p, isPresenter := v.Interface().(presenter); isPresenter && !p.IsPresent() {
+				return nil, false, nil
+			}
+			if n, isNuller := v.Interface().(nuller); isNuller && n.IsNull() {
+				return nil, true, nil
+			}
+			return r.value(v.FieldByName("Val"), path)
+		}
+		switch i := v.Interface().(type) {
+		case driver.Valuer:
+			value, err := i.Value()
+			return value, err == nil, err
+		case encoding.TextMarshaler:
+			text, err := i.MarshalText()
+			return string(text), err == nil, err
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if err := r.enter(v, path); err != nil {
+			return nil, false, err
+		}
+		defer r.leave(v)
+		return r.value(v.Elem(), path)
+	case reflect.Interface:
+		return r.value(v.Elem(), path)
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		if err := r.fields(v, path, m, false); err != nil {
+			return nil, false, err
+		}
+		return m, true, nil
+	case reflect.Map:
+		if err := r.enter(v, path); err != nil {
+			return nil, false, err
+		}
+		defer r.leave(v)
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			var key string
+			switch k := iter.Key(); k.Kind() {
+			case reflect.String:
+				key = k.String()
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				key = strconv.FormatInt(k.Int(), 10)
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				key = strconv.FormatUint(k.Uint(), 10)
+			default:
+				continue
+			}
+			el, ok, err := r.value(iter.Value(), joinPath(path, key))
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				m[key] = el
+			}
+		}
+		return m, true, nil
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return b, true, nil
+		}
+		if v.Kind() == reflect.Slice {
+			if err := r.enter(v, path); err != nil {
+				return nil, false, err
+			}
+			defer r.leave(v)
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			// Elements that are left out are null, so the rest keep their indices.
+			if list[i], _, err = r.value(v.Index(i), joinPath(path, strconv.Itoa(i))); err != nil {
+				return nil, false, err
+			}
+		}
+		return list, true, nil
+	case reflect.String:
+		return v.String(), true, nil
+	case reflect.Bool:
+		return v.Bool(), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return binaryInt(v.Uint()), true, nil
+	case reflect.Float32:
+		// Like encoding/json, a float32 is its shortest float32 text, so 0.1 doesn't become 0.10000000149011612.
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return f, true, nil
+		}
+		format := byte('f')
+		if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+			format = 'e'
+		}
+		return json.Number(strconv.FormatFloat(f, format, -1, 32)), true, nil
+	case reflect.Float64:
+		return v.Float(), true, nil
+	}
+	// Funcs, channels and complex numbers have no meta equivalent.
+	return nil, false, nil
+}
+
+// isMetaValue reports whether t is one of meta's value types, eg String, which have a Val.
+func isMetaValue(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || !reflect.PtrTo(t).Implements(reflectTypeValuer) {
+		return false
+	}
+	_, ok := t.FieldByName("Val")
+	return ok
+}
+
+// fields adds v's fields to m. Fields of embedded structs are promoted, but don't replace fields of the same name.
+func (r *structReader) fields(v reflect.Value, path string, m map[string]interface{}, promoted bool) error {
+	t := v.Type()
+	var embedded []reflect.Value
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitEmpty := structFieldName(field)
+		if name == "-" {
+			continue
+		}
+
+		fieldValue := v.Field(i)
+		if field.Anonymous && name == "" {
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					continue
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			if fieldValue.Kind() == reflect.Struct {
+				embedded = append(embedded, fieldValue)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = NameMapping(field.Name)
+		}
+		if _, exists := m[name]; exists && promoted {
+			continue
+		}
+		if omitEmpty && isEmptyValue(fieldValue) {
+			continue
+		}
+		value, ok, err := r.value(fieldValue, joinPath(path, name))
+		if err != nil {
+			return err
+		}
+		if ok {
+			m[name] = value
+		}
+	}
+
+	for _, e := range embedded {
+		if err := r.fields(e, path, m, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structFieldName is a field's name from its meta or json tag, or "" if it has neither.
+func structFieldName(field reflect.StructField) (name string, omitEmpty bool) {
+	if name, ok := field.Tag.Lookup("meta"); ok {
+		return name, false
+	}
+	parts := strings.Split(field.Tag.Get("json"), ",")
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return parts[0], omitEmpty
+}
+
+// isEmptyValue is encoding/json's definition of empty, for omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
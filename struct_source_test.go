@@ -0,0 +1,185 @@
+package meta
+
+import (
+	"database/sql"
+	"net"
+	"testing"
+	"time"
+)
+
+type jobRow struct {
+	Id        int64
+	UserName  string `json:"name"`
+	Email     sql.NullString
+	Score     float32 `json:"score,omitempty"`
+	Tags      []string
+	Data      []byte
+	IP        net.IP    `meta:"ip"`
+	CreatedAt time.Time `json:"created"`
+	Items     []*jobItem
+	Secret    string `json:"-"`
+	internal  string
+	jobMeta
+}
+
+type jobItem struct {
+	Price int
+	Note  *string
+}
+
+type jobMeta struct {
+	Attempt uint8
+	Id      int64 // shadowed by jobRow.Id
+}
+
+type jobInputs struct {
+	Id      Int64  `meta_required:"true"`
+	Name    String `meta_required:"true"`
+	Email   String `meta_null:"true"`
+	Score   Float64
+	Tags    []String
+	Data    Bytes
+	Ip      String
+	Created Time
+	Secret  String
+	Items   []struct {
+		Price Float64 `meta_min:"1"`
+		Note  String  `meta_null:"true"`
+	}
+	Attempt Int64 `meta_max:"3"`
+}
+
+var jobInputsDecoder = NewDecoder(&jobInputs{})
+
+func TestDecodeStruct(t *testing.T) {
+	note := "fragile"
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	row := jobRow{
+		Id:        7,
+		UserName:  "bob",
+		Tags:      []string{"a", "b"},
+		Data:      []byte{1, 2},
+		IP:        net.IPv4(10, 0, 0, 1),
+		CreatedAt: created,
+		Items:     []*jobItem{{Price: 2, Note: &note}, {Price: 3}},
+		Secret:    "x",
+		internal:  "y",
+		jobMeta:   jobMeta{Attempt: 2, Id: 9},
+	}
+
+	var inputs jobInputs
+	e := jobInputsDecoder.DecodeStruct(&inputs, &row)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.Id.Val, int64(7))
+	assertEqual(t, inputs.Name.Val, "bob")
+	assertEqual(t, inputs.Email.Present, true)
+	assertEqual(t, inputs.Email.Null, true)
+	assertEqual(t, inputs.Score.Present, false)
+	assertEqual(t, len(inputs.Tags), 2)
+	assertEqual(t, inputs.Data.Val, []byte{1, 2})
+	assertEqual(t, inputs.Ip.Val, "10.0.0.1")
+	assertEqual(t, inputs.Created.Val, created)
+	assertEqual(t, inputs.Secret.Present, false)
+	assertEqual(t, len(inputs.Items), 2)
+	assertEqual(t, inputs.Items[0].Note.Val, "fragile")
+	assertEqual(t, inputs.Items[1].Note.Null, true)
+	assertEqual(t, inputs.Attempt.Val, int64(2))
+
+	row.Id = 0
+	row.UserName = ""
+	row.Items[1].Price = 0
+	row.Attempt = 4
+	e = jobInputsDecoder.DecodeStruct(&jobInputs{}, row)
+	assertEqual(t, e, ErrorHash{
+		"name":    ErrBlank,
+		"items":   ErrorSlice{nil, ErrorHash{"price": ErrMin}},
+		"attempt": ErrMax,
+	})
+}
+
+func TestDecodeStructMetaValues(t *testing.T) {
+	src := jobInputs{Id: NewInt64(1), Name: NewString("bob"), Data: NewBytes([]byte{3})}
+	src.Email.Present, src.Email.Null = true, true
+
+	var inputs jobInputs
+	e := jobInputsDecoder.DecodeStruct(&inputs, src)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.Id.Val, int64(1))
+	assertEqual(t, inputs.Email.Null, true)
+	assertEqual(t, inputs.Data.Val, []byte{3})
+	assertEqual(t, inputs.Score.Present, false)
+}
+
+type node struct {
+	Name string
+	Next *node
+}
+
+func TestDecodeStructCycle(t *testing.T) {
+	n := &node{Name: "a"}
+	n.Next = &node{Name: "b", Next: n}
+
+	var inputs struct {
+		Name String
+	}
+	e := NewDecoder(&inputs).DecodeStruct(&inputs, n)
+	assertEqual(t, e, ErrorHash{"error": ErrMalformed})
+
+	n.Next.Next = nil
+	e = NewDecoder(&inputs).DecodeStruct(&inputs, n)
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.Name.Val, "a")
+
+	m := map[string]interface{}{}
+	m["self"] = m
+	e = NewDecoder(&inputs).DecodeStruct(&inputs, struct{ M map[string]interface{} }{m})
+	assertEqual(t, e, ErrorHash{"error": ErrMalformed})
+
+	list := []interface{}{nil}
+	list[0] = list
+	e = NewDecoder(&inputs).DecodeStruct(&inputs, struct{ L []interface{} }{list})
+	assertEqual(t, e, ErrorHash{"error": ErrMalformed})
+
+	// the same map twice isn't a cycle
+	shared := map[string]interface{}{"x": 1}
+	e = NewDecoder(&inputs).DecodeStruct(&inputs, struct{ A, B map[string]interface{} }{shared, shared})
+	assertEqual(t, e, ErrorHash(nil))
+}
+
+func TestDecodeStructNotStruct(t *testing.T) {
+	var inputs struct {
+		Name String
+	}
+	e := NewDecoder(&inputs).DecodeStruct(&inputs, 5)
+	assertEqual(t, e, ErrorHash{"error": ErrMalformed})
+
+	src := NewStructSource("x")
+	assertEqual(t, src.Get("name").Malformed(), true)
+}
+
+func TestStructSourceMetaPointers(t *testing.T) {
+	missing := String{}
+	src := struct {
+		A String
+		B *String
+		C *String
+		D *String
+	}{C: &missing, D: &String{Val: "d", Presence: Presence{true}}}
+
+	tree, err := structTree(src)
+	assertEqual(t, err, nil)
+	assertEqual(t, tree, map[string]interface{}{"d": "d"})
+}
+
+func TestDecodeStructFloat32(t *testing.T) {
+	var inputs struct {
+		A String
+		B Float64
+		C Int64
+	}
+	e := NewDecoder(&inputs).DecodeStruct(&inputs, struct{ A, B, C float32 }{0.1, 0.1, 1e6})
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, "0.1")
+	assertEqual(t, inputs.B.Val, 0.1)
+	assertEqual(t, inputs.C.Val, int64(1000000))
+}
@@ -2,6 +2,7 @@ package meta
 
 import (
 	"database/sql/driver"
+	"fmt"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -200,6 +201,12 @@ func (t *Time) FormValue(value string, options interface{}) Errorable {
 	return ErrTime
 }
 
+// equalValue compares instants, not how they were written, for DetectConflicts.
+func (t Time) equalValue(other interface{}) bool {
+	o, ok := other.(Time)
+	return ok && t.Present == o.Present && t.Null == o.Null && t.Val.Equal(o.Val)
+}
+
 func (t Time) Value() (driver.Value, error) {
 	if t.Present && !t.Null {
 		return t.Val, nil
@@ -207,9 +214,76 @@ func (t Time) Value() (driver.Value, error) {
 	return nil, nil
 }
 
+// sqlTimeFormats are the text formats Scan accepts for drivers that return times as strings.
+var sqlTimeFormats = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999", "2006-01-02"}
+
+// Scan implements sql.Scanner. A NULL column sets Null.
+func (t *Time) Scan(src interface{}) error {
+	t.Val, t.Null, t.Present = time.Time{}, false, true
+	var str string
+	switch value := src.(type) {
+	case nil:
+		t.Null = true
+		return nil
+	case time.Time:
+		t.Val = value
+		return nil
+	case string:
+		str = value
+	case []byte:
+		str = string(value)
+	default:
+		t.Present = false
+		return fmt.Errorf("meta: cannot scan %T into Time", src)
+	}
+	for _, format := range sqlTimeFormats {
+		if v, err := time.Parse(format, str); err == nil {
+			t.Val = v
+			return nil
+		}
+	}
+	t.Present = false
+	return fmt.Errorf("meta: cannot scan %q into Time", str)
+}
+
 func (t Time) MarshalJSON() ([]byte, error) {
 	if t.Present && !t.Null {
 		return MetaJson.Marshal(t.Val)
 	}
 	return nullString, nil
 }
+
+// UnmarshalJSON implements json.Unmarshaler using the default options. null sets Null, so values round trip through MarshalJSON.
+func (t *Time) UnmarshalJSON(data []byte) error {
+	*t = Time{}
+	if isJSONNull(data) {
+		t.Present = true
+		t.Null = true
+		return nil
+	}
+	opts := t.ParseOptions("")
+	return unmarshalJSONValue(t, data, opts)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using the default options.
+func (t *Time) UnmarshalText(text []byte) error {
+	*t = Time{}
+	opts := t.ParseOptions("")
+	if err := t.FormValue(string(text), opts); err != nil {
+		return err
+	}
+	return nil
+}
+
+// String implements flag.Value.
+func (t *Time) String() string {
+	if !t.Present || t.Null {
+		return ""
+	}
+	return t.Val.Format(time.RFC3339Nano)
+}
+
+// Set implements flag.Value using the default options.
+func (t *Time) Set(value string) error {
+	return t.UnmarshalText([]byte(value))
+}
@@ -0,0 +1,254 @@
+package meta
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// XMLTextKey is the key of an element's text when it also has attributes or child elements,
+// eg the 10 in <price currency="USD">10</price>.
+const XMLTextKey = "#text"
+
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+// DecodeXML decodes an XML document, like DecodeJSON. XML that can't be parsed is ErrMalformed.
+func (d *Decoder) DecodeXML(dest interface{}, b []byte) ErrorHash {
+	return d.decodeBody(dest, b, NewXMLSource)
+}
+
+// NewXMLSource reads an XML document. The root element is the struct being decoded; its attributes and child elements
+// are its fields. Names go through NameMapping, with dashes as underscores, so <MaxConns>, <max-conns> and max_conns=""
+// all fill a field MaxConns. Namespaces are ignored, and an element with xsi:nil="true" is null.
+//
+// An element that's repeated is a slice, and so is a single element read by a slice field. A slice can also be wrapped
+// in an element of its own, eg <tags><tag>a</tag><tag>b</tag></tags> for a field Tags []String.
+func NewXMLSource(b []byte) Source {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return &xmlSource{}
+	}
+	value, err := parseXML(b)
+	if err != nil {
+		return &xmlSource{present: true, invalid: true}
+	}
+	return &xmlSource{value: value, present: true}
+}
+
+// xmlElement is an element that's being parsed.
+type xmlElement struct {
+	name  string
+	m     map[string]interface{} // attributes and child elements; nil if there aren't any
+	text  bytes.Buffer
+	isNil bool
+}
+
+func (e *xmlElement) value() interface{} {
+	if e.isNil {
+		return nil
+	}
+	text := strings.TrimSpace(e.text.String())
+	if e.m == nil {
+		return text
+	}
+	if text != "" {
+		e.m[XMLTextKey] = text
+	}
+	return e.m
+}
+
+// add adds a child element or attribute. A repeated name becomes a []interface{}.
+func (e *xmlElement) add(name string, value interface{}) {
+	if e.m == nil {
+		e.m = make(map[string]interface{})
+	}
+	switch existing := e.m[name].(type) {
+	case nil:
+		if _, ok := e.m[name]; !ok {
+			e.m[name] = value
+			return
+		}
+		e.m[name] = []interface{}{nil, value}
+	case []interface{}:
+		e.m[name] = append(existing, value)
+	default:
+		e.m[name] = []interface{}{existing, value}
+	}
+}
+
+func xmlKey(name xml.Name) string {
+	return NameMapping(strings.Replace(name.Local, "-", "_", -1))
+}
+
+// parseXML parses b into the value of its root element: a map[string]interface{}, a string, or nil.
+func parseXML(b []byte) (interface{}, error) {
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	var stack []*xmlElement
+	var root interface{}
+	seenRoot := false
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 && seenRoot {
+				return nil, &xml.SyntaxError{Msg: "more than one root element", Line: 1}
+			}
+			e := &xmlElement{name: xmlKey(t.Name)}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+					continue
+				}
+				if attr.Name.Space == xsiNamespace {
+					if attr.Name.Local == "nil" {
+						e.isNil, _ = strconv.ParseBool(attr.Value)
+					}
+					continue
+				}
+				if _, ok := e.m[xmlKey(attr.Name)]; ok {
+					return nil, &xml.SyntaxError{Msg: "attribute " + attr.Name.Local + " redefined", Line: 1}
+				}
+				e.add(xmlKey(attr.Name), attr.Value)
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			e := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				root, seenRoot = e.value(), true
+			} else {
+				stack[len(stack)-1].add(e.name, e.value())
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			} else if len(bytes.TrimSpace(t)) > 0 {
+				return nil, &xml.SyntaxError{Msg: "text outside of the root element", Line: 1}
+			}
+		}
+	}
+	if !seenRoot {
+		return nil, &xml.SyntaxError{Msg: "no root element", Line: 1}
+	}
+	return root, nil
+}
+
+//
+// xml source
+//
+
+type xmlSource struct {
+	value     interface{}
+	present   bool
+	invalid   bool // the input couldn't be parsed, so every child is malformed
+	malformed bool
+	path      string
+	// unwrapped is the content of a slice element with a single child, eg <tags><tag>a</tag></tags>, which may be
+	// the element itself or a wrapper around it. It's used if the element itself doesn't have what's asked for.
+	unwrapped interface{}
+}
+
+func (s *xmlSource) Get(key string) Source {
+	child := &xmlSource{
+		malformed: s.malformed || s.invalid,
+		path:      joinPath(s.path, key),
+	}
+	if !s.present || child.malformed {
+		return child
+	}
+
+	if i, err := strconv.Atoi(key); err == nil {
+		child.value, child.unwrapped, child.present = xmlIndex(s.value, i)
+		return child
+	}
+	if m, ok := s.value.(map[string]interface{}); ok {
+		child.value, child.present = m[key]
+	}
+	if m, ok := s.unwrapped.(map[string]interface{}); ok && !child.present {
+		child.value, child.present = m[key]
+	}
+	return child
+}
+
+// xmlIndex returns the i-th element of value read as a slice: a repeated element, a single element,
+// or an element that wraps either of those. An empty or nil element is an empty slice.
+// A single element with one child can't be told apart from a wrapper around one element, so its child is unwrapped too.
+func xmlIndex(value interface{}, i int) (el interface{}, unwrapped interface{}, ok bool) {
+	if value == nil || value == "" {
+		return nil, nil, false
+	}
+	if m, ok := value.(map[string]interface{}); ok && len(m) == 1 {
+		for _, wrapped := range m {
+			if list, ok := wrapped.([]interface{}); ok {
+				value = list
+			} else {
+				unwrapped = wrapped
+			}
+		}
+	}
+	if list, ok := value.([]interface{}); ok {
+		if i >= 0 && i < len(list) {
+			return list[i], nil, true
+		}
+		return nil, nil, false
+	}
+	if i != 0 {
+		return nil, nil, false
+	}
+	return value, unwrapped, true
+}
+
+func (s *xmlSource) Value(i interface{}) Errorable {
+	if !s.present {
+		return ErrBlank
+	}
+	if s.invalid {
+		return ErrMalformed
+	}
+
+	switch v := i.(type) {
+	case *interface{}:
+		*v = s.value
+		if _, isMap := s.value.(map[string]interface{}); isMap && s.unwrapped != nil {
+			if _, isMap := s.unwrapped.(map[string]interface{}); !isMap {
+				*v = s.unwrapped
+			}
+		}
+	default:
+		return ErrBlank
+	}
+	return nil
+}
+
+func (s *xmlSource) Empty() bool {
+	return !s.present
+}
+
+func (s *xmlSource) ValueMap() map[string]interface{} {
+	// a wrapper around a slice's only element has that element's fields
+	if wrapped, ok := s.unwrapped.(map[string]interface{}); ok {
+		return wrapped
+	}
+	out, _ := s.value.(map[string]interface{})
+	return out
+}
+
+func (s *xmlSource) Malformed() bool {
+	return s.malformed
+}
+
+func (s *xmlSource) Path() string {
+	return s.path
+}
+
+func (s *xmlSource) tree() (interface{}, bool) {
+	return s.value, !s.invalid
+}
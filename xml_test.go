@@ -0,0 +1,122 @@
+package meta
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseXML(t *testing.T) {
+	tests := []struct {
+		xml  string
+		want interface{}
+	}{
+		{`<r><a>1</a><b>x</b></r>`, map[string]interface{}{"a": "1", "b": "x"}},
+		{`<?xml version="1.0"?><r id="5"><MaxConns> 10 </MaxConns><max-idle/></r>`,
+			map[string]interface{}{"id": "5", "max_conns": "10", "max_idle": ""}},
+		{`<r><t>a</t><t>b</t><t>c</t></r>`, map[string]interface{}{"t": []interface{}{"a", "b", "c"}}},
+		{`<r><price currency="USD">10</price></r>`, map[string]interface{}{"price": map[string]interface{}{"currency": "USD", "#text": "10"}}},
+		{`<r xmlns="urn:x" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><a xsi:nil="true"/></r>`, map[string]interface{}{"a": nil}},
+		{`<r><a><![CDATA[<b>]]></a></r>`, map[string]interface{}{"a": "<b>"}},
+		{`<r>text</r>`, "text"},
+	}
+	for _, test := range tests {
+		got, err := parseXML([]byte(test.xml))
+		if err != nil {
+			t.Errorf("%q: %s", test.xml, err)
+			continue
+		}
+		assertEqual(t, got, test.want)
+	}
+
+	for _, xml := range []string{`<r><a></r>`, `<r></r><r></r>`, `text`, `<r>&nope;</r>`, `<r a="1" a="2"/>`} {
+		if _, err := parseXML([]byte(xml)); err == nil {
+			t.Errorf("%q: expected an error", xml)
+		}
+	}
+}
+
+type withXML struct {
+	Id    Int64  `meta_required:"true"`
+	Name  String `meta_required:"true"`
+	Tags  []String
+	Ids   []Int64
+	Items []struct {
+		Price Float64 `meta_required:"true"`
+	}
+	Address struct {
+		City String
+	}
+}
+
+var withXMLDecoder = NewDecoder(&withXML{})
+
+func TestDecodeXML(t *testing.T) {
+	var inputs withXML
+	e := withXMLDecoder.DecodeXML(&inputs, []byte(`
+<order id="7">
+  <name>x</name>
+  <tags><tag>a</tag><tag>b</tag></tags>
+  <ids>1</ids>
+  <items><price>1.5</price></items>
+  <items><price>2</price></items>
+  <address><city>Paris</city></address>
+</order>`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.Id.Val, int64(7))
+	assertEqual(t, inputs.Name.Val, "x")
+	assertEqual(t, len(inputs.Tags), 2)
+	assertEqual(t, inputs.Tags[1].Val, "b")
+	assertEqual(t, len(inputs.Ids), 1)
+	assertEqual(t, inputs.Ids[0].Val, int64(1))
+	assertEqual(t, len(inputs.Items), 2)
+	assertEqual(t, inputs.Items[1].Price.Val, 2.0)
+	assertEqual(t, inputs.Address.City.Val, "Paris")
+
+	// A wrapper around a single element
+	inputs = withXML{}
+	e = withXMLDecoder.DecodeXML(&inputs, []byte(`
+<order id="8">
+  <name>y</name>
+  <tags><tag>a</tag></tags>
+  <items><item><price>1</price></item></items>
+</order>`))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, len(inputs.Tags), 1)
+	assertEqual(t, inputs.Tags[0].Val, "a")
+	assertEqual(t, len(inputs.Items), 1)
+	assertEqual(t, inputs.Items[0].Price.Val, 1.0)
+
+	strict := NewDecoderWithOptions(&withXML{}, DecoderOptions{DisallowUnknown: true})
+	e = strict.DecodeXML(&inputs, []byte(`<order id="8"><name>y</name><items><item><price>1</price></item></items></order>`))
+	assertEqual(t, e, ErrorHash(nil))
+
+	inputs = withXML{}
+	e = withXMLDecoder.DecodeXML(&inputs, []byte(`<order id="x"><tags/><items><price>1</price></items><items/></order>`))
+	assertEqual(t, e, ErrorHash{
+		"id":    ErrInt,
+		"name":  ErrRequired,
+		"items": ErrorSlice{nil, ErrorHash{"price": ErrRequired}},
+	})
+	assertEqual(t, len(inputs.Tags), 0)
+
+	e = withXMLDecoder.DecodeXML(&inputs, []byte(`<order><name>x</order>`))
+	assertEqual(t, e, ErrorHash{"error": ErrMalformed})
+
+	e = withXMLDecoder.DecodeXML(&inputs, []byte(``))
+	assertEqual(t, e, ErrorHash{"id": ErrRequired, "name": ErrRequired})
+
+	d := NewDecoderWithOptions(&withXML{}, DecoderOptions{MaxElements: 2})
+	e = d.DecodeXML(&inputs, []byte(`<order><ids>1</ids><ids>2</ids><ids>3</ids></order>`))
+	assertEqual(t, e, ErrorHash{"error": ErrMaxElements})
+}
+
+func TestDecodeRequestXML(t *testing.T) {
+	assertDecodesRequest(t, "application/xml; charset=utf-8", `<in a="1"><c><d>3</d></c></in>`)
+
+	var inputs withRequest
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`<in><a>1</in>`))
+	req.Header.Set("Content-Type", "application/atom+xml")
+	e := withRequestDecoder.DecodeRequest(&inputs, req)
+	assertEqual(t, e, ErrorHash{"error": ErrMalformed})
+}
@@ -0,0 +1,991 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxYAMLNodes bounds how many values a YAML input can expand to through aliases, so a few lines of nested aliases
+// (a "billion laughs") can't make decoding run forever.
+const maxYAMLNodes = 1 << 20
+
+// DecodeYAML decodes a YAML document, like DecodeJSON. YAML that can't be parsed, or a stream of more than one
+// document, is ErrMalformed.
+func (d *Decoder) DecodeYAML(dest interface{}, b []byte) ErrorHash {
+	return d.decodeBody(dest, b, NewYAMLSource)
+}
+
+// NewYAMLSource parses a YAML document into the same tree as NewJSONSource, so paths and errors are the same as for JSON.
+// Plain scalars are resolved with the YAML 1.2 core schema: 10 and 1.5 are numbers, true is a bool and ~ is null.
+// Quoted scalars are always strings, and so are numbers JSON can't write, eg 0x1F, unless they're tagged !!int or !!float.
+//
+// Block and flow collections, all scalar styles, anchors and aliases, and merge keys (<<) are supported.
+// Complex keys (?) aren't. YAML that can't be parsed, or a stream of more than one document, is malformed;
+// see NewYAMLStream.
+func NewYAMLSource(b []byte) Source {
+	docs, err := parseYAML(b)
+	if err != nil || len(docs) > 1 {
+		return &jsonSource{present: true, invalid: true}
+	}
+	if len(docs) == 0 {
+		return &jsonSource{}
+	}
+	return &jsonSource{value: docs[0], present: true}
+}
+
+// NewYAMLStream parses a stream of YAML documents separated by --- and returns a source for each one.
+// A --- at the end of the stream doesn't start another document.
+func NewYAMLStream(b []byte) ([]Source, error) {
+	docs, err := parseYAML(b)
+	if err != nil {
+		return nil, err
+	}
+	srcs := make([]Source, len(docs))
+	for i, doc := range docs {
+		srcs[i] = &jsonSource{value: doc, present: true}
+	}
+	return srcs, nil
+}
+
+type yamlLine struct {
+	num    int // for errors
+	indent int
+	text   string // the line after its indentation
+}
+
+func (l yamlLine) blank() bool {
+	return l.text == "" || l.text[0] == '#'
+}
+
+type yamlParser struct {
+	lines   []yamlLine
+	pos     int
+	anchors map[string]yamlAnchor
+	nodes   int
+}
+
+type yamlAnchor struct {
+	value interface{}
+	nodes int
+}
+
+type yamlError struct {
+	line int
+	msg  string
+}
+
+func (e *yamlError) Error() string {
+	return fmt.Sprintf("meta: yaml: line %d: %s", e.line, e.msg)
+}
+
+func yamlErrorf(line int, format string, args ...interface{}) error {
+	return &yamlError{line: line, msg: fmt.Sprintf(format, args...)}
+}
+
+// parseYAML splits a stream into documents and parses each one.
+func parseYAML(b []byte) ([]interface{}, error) {
+	if !utf8.Valid(b) {
+		return nil, yamlErrorf(1, "invalid UTF-8")
+	}
+	text := strings.TrimPrefix(string(b), "\ufeff")
+
+	var docs []interface{}
+	var lines []yamlLine
+	started := false // the document was started with ---, so it's there even if it's empty
+	anchors := make(map[string]yamlAnchor)
+	nodes := 0
+
+	// A stream can end with a --- that starts no document, as generators often write; last drops that document.
+	flush := func(last bool) error {
+		hasContent := false
+		for _, l := range lines {
+			if !l.blank() {
+				hasContent = true
+				break
+			}
+		}
+		if hasContent || started && !(last && len(docs) > 0) {
+			p := &yamlParser{lines: lines, anchors: anchors, nodes: nodes}
+			doc, err := p.parseDocument()
+			if err != nil {
+				return err
+			}
+			docs = append(docs, doc)
+			nodes = p.nodes
+		}
+		lines, started = nil, false
+		return nil
+	}
+
+	rawLines := strings.Split(text, "\n")
+	if strings.HasSuffix(text, "\n") {
+		rawLines = rawLines[:len(rawLines)-1]
+	}
+	for i, raw := range rawLines {
+		raw = strings.TrimSuffix(raw, "\r")
+		num := i + 1
+
+		if raw == "---" || strings.HasPrefix(raw, "--- ") || strings.HasPrefix(raw, "---\t") {
+			if err := flush(false); err != nil {
+				return nil, err
+			}
+			started = true
+			if rest := strings.TrimLeft(raw[3:], " \t"); rest != "" && rest[0] != '#' {
+				lines = append(lines, yamlLine{num: num, text: rest})
+			}
+			continue
+		}
+		if raw == "..." || strings.HasPrefix(raw, "... ") {
+			if err := flush(false); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if strings.HasPrefix(raw, "%") && !started && len(lines) == 0 {
+			continue // a directive, eg %YAML 1.2
+		}
+
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+		rest := raw[indent:]
+		if strings.HasPrefix(rest, "\t") && strings.TrimSpace(rest) != "" {
+			return nil, yamlErrorf(num, "tabs can't be used for indentation")
+		}
+		if strings.TrimSpace(rest) == "" {
+			rest = ""
+		}
+		lines = append(lines, yamlLine{num: num, indent: indent, text: strings.TrimRight(rest, " \t")})
+	}
+	if err := flush(true); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (p *yamlParser) parseDocument() (interface{}, error) {
+	p.skipBlank()
+	if p.pos >= len(p.lines) {
+		return nil, nil
+	}
+	doc, err := p.parseBlock(p.lines[p.pos].indent)
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if p.pos < len(p.lines) {
+		return nil, yamlErrorf(p.lines[p.pos].num, "unexpected %q", p.lines[p.pos].text)
+	}
+	return doc, nil
+}
+
+func (p *yamlParser) skipBlank() {
+	for p.pos < len(p.lines) && p.lines[p.pos].blank() {
+		p.pos++
+	}
+}
+
+func (p *yamlParser) count(n int, line int) error {
+	p.nodes += n
+	if p.nodes > maxYAMLNodes {
+		return yamlErrorf(line, "document is too large")
+	}
+	return nil
+}
+
+// parseBlock parses the node that starts on the current line, which is indented by indent.
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	line := p.lines[p.pos]
+	if isYAMLSeqItem(line.text) {
+		return p.parseSequence(indent)
+	}
+	if _, _, ok, err := splitYAMLKey(line.text, line.num); err != nil {
+		return nil, err
+	} else if ok {
+		return p.parseMapping(indent)
+	}
+	return p.parseValue(line.text, indent-1, false)
+}
+
+func (p *yamlParser) parseMapping(indent int) (interface{}, error) {
+	m := make(map[string]interface{})
+	var merges []interface{}
+	var mergeLine int
+
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.lines) || p.lines[p.pos].indent < indent {
+			break
+		}
+		line := p.lines[p.pos]
+		if line.indent > indent {
+			return nil, yamlErrorf(line.num, "bad indentation")
+		}
+		key, rest, ok, err := splitYAMLKey(line.text, line.num)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if isYAMLSeqItem(line.text) {
+				return nil, yamlErrorf(line.num, "unexpected sequence item in a mapping")
+			}
+			return nil, yamlErrorf(line.num, "expected a key, got %q", line.text)
+		}
+		if err := p.count(1, line.num); err != nil {
+			return nil, err
+		}
+
+		value, err := p.parseValue(rest, indent, true)
+		if err != nil {
+			return nil, err
+		}
+		if key == "<<" {
+			merges = append(merges, value)
+			mergeLine = line.num
+			continue
+		}
+		if _, ok := m[key]; ok {
+			return nil, yamlErrorf(line.num, "duplicate key %q", key)
+		}
+		m[key] = value
+	}
+
+	// Keys in the mapping itself win over merged ones, and earlier merges win over later ones.
+	for _, merge := range merges {
+		maps, ok := merge.([]interface{})
+		if !ok {
+			maps = []interface{}{merge}
+		}
+		for _, mm := range maps {
+			mergeMap, ok := mm.(map[string]interface{})
+			if !ok {
+				return nil, yamlErrorf(mergeLine, "<< needs a mapping or a sequence of mappings")
+			}
+			for k, v := range mergeMap {
+				if _, ok := m[k]; !ok {
+					m[k] = v
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+func (p *yamlParser) parseSequence(indent int) (interface{}, error) {
+	var s []interface{}
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.lines) || p.lines[p.pos].indent < indent {
+			break
+		}
+		line := p.lines[p.pos]
+		if line.indent > indent {
+			return nil, yamlErrorf(line.num, "bad indentation")
+		}
+		if !isYAMLSeqItem(line.text) {
+			break // eg the next key of a mapping that this sequence is a value in
+		}
+		if err := p.count(1, line.num); err != nil {
+			return nil, err
+		}
+
+		rest := strings.TrimLeft(line.text[1:], " ")
+		var value interface{}
+		var err error
+		_, _, isKey, keyErr := splitYAMLKey(rest, line.num)
+		if keyErr != nil {
+			return nil, keyErr
+		}
+		if isKey || isYAMLSeqItem(rest) {
+			// "- a: 1" or "- - a": the item is a block that starts on this line, after the dash.
+			itemIndent := indent + len(line.text) - len(rest)
+			p.lines[p.pos] = yamlLine{num: line.num, indent: itemIndent, text: rest}
+			value, err = p.parseBlock(itemIndent)
+		} else {
+			value, err = p.parseValue(rest, indent, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, value)
+	}
+	return s, nil
+}
+
+// parseValue parses text, the rest of the current line after "key:" or "-", and any lines that belong to it.
+// indent is the indentation of the key or dash, so lines that belong to the value are indented more. A mapping's
+// value can also be a sequence at the same indentation as its key.
+func (p *yamlParser) parseValue(text string, indent int, inMapping bool) (interface{}, error) {
+	num := p.lines[p.pos].num
+	start := p.nodes
+
+	// Properties: an &anchor and a !tag, in either order
+	var anchor, tag string
+	for {
+		text = strings.TrimLeft(text, " \t")
+		if strings.HasPrefix(text, "&") || strings.HasPrefix(text, "!") {
+			end := strings.IndexAny(text, " \t")
+			if end < 0 {
+				end = len(text)
+			}
+			if text[0] == '&' {
+				anchor = text[1:end]
+			} else {
+				tag = text[:end]
+			}
+			text = text[end:]
+			continue
+		}
+		break
+	}
+	if strings.HasPrefix(text, "#") {
+		text = ""
+	}
+
+	var value interface{}
+	var err error
+	switch {
+	case text == "":
+		p.pos++
+		p.skipBlank()
+		if p.pos < len(p.lines) {
+			next := p.lines[p.pos]
+			if next.indent > indent || (inMapping && next.indent == indent && isYAMLSeqItem(next.text)) {
+				value, err = p.parseBlock(next.indent)
+				break
+			}
+		}
+		value = applyYAMLTag(nil, "", tag)
+	case text[0] == '*':
+		name := strings.TrimSpace(stripYAMLComment(text[1:]))
+		a, ok := p.anchors[name]
+		if !ok {
+			return nil, yamlErrorf(num, "unknown alias %q", name)
+		}
+		if err := p.count(a.nodes, num); err != nil {
+			return nil, err
+		}
+		p.pos++
+		value = a.value
+	case text[0] == '|' || text[0] == '>':
+		var s string
+		s, err = p.parseBlockScalar(text, indent)
+		value = applyYAMLTag(s, s, tag)
+	case text[0] == '[' || text[0] == '{':
+		value, err = p.parseFlowLines(text, indent)
+	case text[0] == '"' || text[0] == '\'':
+		var s string
+		s, err = p.parseQuotedLines(text)
+		value = applyYAMLTag(s, s, tag)
+	default:
+		var s string
+		s, err = p.parsePlainLines(text, indent)
+		value = applyYAMLTag(resolveYAMLScalar(s), s, tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.count(1, num); err != nil {
+		return nil, err
+	}
+	if anchor != "" {
+		p.anchors[anchor] = yamlAnchor{value: value, nodes: p.nodes - start}
+	}
+	return value, nil
+}
+
+// parsePlainLines reads a plain scalar, which can continue on more indented lines.
+func (p *yamlParser) parsePlainLines(text string, indent int) (string, error) {
+	parts := []string{strings.TrimSpace(stripYAMLComment(text))}
+	p.pos++
+	for p.pos < len(p.lines) {
+		next := p.lines[p.pos]
+		if next.blank() || next.indent <= indent {
+			break
+		}
+		if _, _, ok, _ := splitYAMLKey(next.text, next.num); ok {
+			return "", yamlErrorf(next.num, "bad indentation")
+		}
+		parts = append(parts, strings.TrimSpace(stripYAMLComment(next.text)))
+		p.pos++
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// parseQuotedLines reads a quoted scalar, which can continue on the following lines.
+func (p *yamlParser) parseQuotedLines(text string) (string, error) {
+	num := p.lines[p.pos].num
+	p.pos++
+	for {
+		s, n, ok, err := scanYAMLQuoted(text, num)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			if rest := strings.TrimSpace(text[n:]); rest != "" && rest[0] != '#' {
+				return "", yamlErrorf(num, "unexpected %q after a quoted scalar", rest)
+			}
+			return s, nil
+		}
+		if p.pos >= len(p.lines) {
+			return "", yamlErrorf(num, "unterminated quoted scalar")
+		}
+		text += "\n" + p.lines[p.pos].text
+		p.pos++
+	}
+}
+
+// parseFlowLines reads a flow collection, eg [a, b] or {a: 1}, which can continue on the following lines.
+func (p *yamlParser) parseFlowLines(text string, indent int) (interface{}, error) {
+	num := p.lines[p.pos].num
+	text = stripYAMLComment(text)
+	p.pos++
+	for !yamlFlowClosed(text) {
+		if p.pos >= len(p.lines) {
+			return nil, yamlErrorf(num, "unterminated flow collection")
+		}
+		if next := p.lines[p.pos]; !next.blank() {
+			text += "\n" + stripYAMLComment(next.text)
+		}
+		p.pos++
+	}
+
+	f := &yamlFlow{p: p, s: text, line: num}
+	value, err := f.value()
+	if err != nil {
+		return nil, err
+	}
+	f.skipSpace()
+	if f.i < len(f.s) {
+		return nil, yamlErrorf(num, "unexpected %q after a flow collection", f.s[f.i:])
+	}
+	return value, nil
+}
+
+// parseBlockScalar reads a literal (|) or folded (>) block scalar. header is the indicator and its options,
+// eg "|-" or ">2".
+func (p *yamlParser) parseBlockScalar(header string, indent int) (string, error) {
+	num := p.lines[p.pos].num
+	chomp := byte(0)
+	explicit := 0
+	for _, c := range []byte(strings.TrimSpace(stripYAMLComment(header[1:]))) {
+		switch {
+		case (c == '-' || c == '+') && chomp == 0:
+			chomp = c
+		case c >= '1' && c <= '9' && explicit == 0:
+			explicit = int(c - '0')
+		default:
+			return "", yamlErrorf(num, "bad block scalar header %q", header)
+		}
+	}
+	p.pos++
+
+	contentIndent := -1
+	if explicit > 0 {
+		contentIndent = indent + explicit
+		if indent < 0 {
+			contentIndent = explicit
+		}
+	}
+
+	var lines []string
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.text == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		if contentIndent < 0 {
+			if line.indent <= indent {
+				break
+			}
+			contentIndent = line.indent
+		}
+		if line.indent < contentIndent {
+			break
+		}
+		lines = append(lines, strings.Repeat(" ", line.indent-contentIndent)+line.text)
+		p.pos++
+	}
+
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var s string
+	if header[0] == '|' {
+		s = strings.Join(lines, "\n")
+	} else {
+		s = foldYAMLLines(lines)
+	}
+
+	switch chomp {
+	case '-':
+		return s, nil
+	case '+':
+		if len(lines) > 0 {
+			s += "\n"
+		}
+		return s + strings.Repeat("\n", trailing), nil
+	}
+	if len(lines) > 0 {
+		s += "\n"
+	}
+	return s, nil
+}
+
+// foldYAMLLines joins the lines of a folded block scalar: lines are joined with spaces, an empty line is a line break,
+// and lines that are indented more keep their line breaks.
+func foldYAMLLines(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			prevNormal := prev != "" && prev[0] != ' '
+			switch {
+			case prevNormal && line != "" && line[0] != ' ':
+				b.WriteByte(' ')
+			case prevNormal && line == "":
+				// the break is folded away; the empty line is the break
+			default:
+				b.WriteByte('\n')
+			}
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// isYAMLSeqItem returns whether text starts a block sequence item.
+func isYAMLSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ") || strings.HasPrefix(text, "-\t")
+}
+
+// splitYAMLKey splits "key: value" into its key and the rest of the line. ok is false if text isn't a key.
+func splitYAMLKey(text string, num int) (key, rest string, ok bool, err error) {
+	if text == "" {
+		return "", "", false, nil
+	}
+	switch text[0] {
+	case '"', '\'':
+		s, n, closed, err := scanYAMLQuoted(text, num)
+		if err != nil || !closed {
+			return "", "", false, nil // a quoted scalar, maybe over more than one line
+		}
+		after := strings.TrimLeft(text[n:], " \t")
+		if !strings.HasPrefix(after, ":") || (len(after) > 1 && after[1] != ' ' && after[1] != '\t') {
+			return "", "", false, nil
+		}
+		return s, after[1:], true, nil
+	case '?':
+		if text == "?" || strings.HasPrefix(text, "? ") {
+			return "", "", false, yamlErrorf(num, "complex keys aren't supported")
+		}
+	case '[', '{', '&', '*', '!', '|', '>', '#', '%', '@', '`':
+		return "", "", false, nil
+	case '-':
+		if isYAMLSeqItem(text) {
+			return "", "", false, nil
+		}
+	}
+
+	text = stripYAMLComment(text)
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i == len(text)-1 || text[i+1] == ' ' || text[i+1] == '\t') {
+			return strings.TrimRight(text[:i], " \t"), text[i+1:], true, nil
+		}
+	}
+	return "", "", false, nil
+}
+
+// stripYAMLComment removes a comment from the end of a line. A # starts a comment at the start of the line or after
+// whitespace, outside of quotes.
+func stripYAMLComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote == '\'' && c == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && yamlTokenStart(text, i):
+			quote = c
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return strings.TrimRight(text[:i], " \t")
+		}
+	}
+	return text
+}
+
+// yamlTokenStart returns whether text[i] can start a scalar, so a quote there opens a quoted scalar.
+func yamlTokenStart(text string, i int) bool {
+	return i == 0 || strings.IndexByte(" \t\n[{,:-", text[i-1]) >= 0
+}
+
+// yamlFlowClosed returns whether every bracket in text is closed.
+func yamlFlowClosed(text string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote == '\'' && c == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && yamlTokenStart(text, i):
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth <= 0 && quote == 0
+}
+
+// scanYAMLQuoted reads the quoted scalar at the start of text. n is how much of text it took, and ok is false if
+// the closing quote wasn't found. Line breaks are folded: a single break is a space, and each empty line is a break.
+func scanYAMLQuoted(text string, num int) (s string, n int, ok bool, err error) {
+	quote := text[0]
+	var b strings.Builder
+	for i := 1; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == quote && quote == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			b.WriteByte('\'')
+			i++
+		case c == quote:
+			return b.String(), i + 1, true, nil
+		case c == '\n':
+			// trailing whitespace before a break is dropped, and so is leading whitespace after it
+			str := strings.TrimRight(b.String(), " \t")
+			b.Reset()
+			b.WriteString(str)
+			breaks := 0
+			for i+1 < len(text) && (text[i+1] == '\n' || text[i+1] == ' ' || text[i+1] == '\t') {
+				if text[i+1] == '\n' {
+					breaks++
+				}
+				i++
+			}
+			if breaks == 0 {
+				b.WriteByte(' ')
+			} else {
+				b.WriteString(strings.Repeat("\n", breaks))
+			}
+		case c == '\\' && quote == '"':
+			if i+1 >= len(text) {
+				return "", 0, false, nil
+			}
+			i++
+			if text[i] == '\n' {
+				// an escaped line break joins the lines without a space
+				for i+1 < len(text) && (text[i+1] == ' ' || text[i+1] == '\t') {
+					i++
+				}
+				continue
+			}
+			r, size, err := yamlEscape(text[i:], num)
+			if err != nil {
+				return "", 0, false, err
+			}
+			b.WriteString(r)
+			i += size - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, false, nil
+}
+
+var yamlEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v", 'f': "\f", 'r': "\r",
+	'e': "\x1b", ' ': " ", '"': "\"", '/': "/", '\\': "\\", 'N': "\u0085", '_': " ", 'L': " ", 'P': " ",
+}
+
+// yamlEscape decodes the escape sequence at the start of text, after the backslash, and returns how long it was.
+func yamlEscape(text string, num int) (string, int, error) {
+	if s, ok := yamlEscapes[text[0]]; ok {
+		return s, 1, nil
+	}
+	digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[text[0]]
+	if digits == 0 || len(text) < 1+digits {
+		return "", 0, yamlErrorf(num, "bad escape \\%c", text[0])
+	}
+	code, err := strconv.ParseUint(text[1:1+digits], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(code)) {
+		return "", 0, yamlErrorf(num, "bad escape \\%s", text[:1+digits])
+	}
+	return string(rune(code)), 1 + digits, nil
+}
+
+var (
+	yamlInt        = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlFloat      = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	yamlJSONNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+)
+
+// resolveYAMLScalar resolves a plain scalar with the core schema, to nil, a bool, a json.Number or a string.
+// Only numbers that JSON can write are numbers. The core schema's other forms, eg 0x1F, 012, +12 and .inf, keep
+// their text, like a quoted JSON string would, so a String gets what was written.
+func resolveYAMLScalar(s string) interface{} {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if yamlJSONNumber.MatchString(s) {
+		return json.Number(s)
+	}
+	return s
+}
+
+// yamlNumber converts any of the core schema's numbers, eg 0x1F, 0o17, +12 or .inf, to a json.Number, for !!int and !!float.
+func yamlNumber(s string) (json.Number, bool) {
+	switch s {
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF":
+		return json.Number("+Inf"), true
+	case "-.inf", "-.Inf", "-.INF":
+		return json.Number("-Inf"), true
+	case ".nan", ".NaN", ".NAN":
+		return json.Number("NaN"), true
+	}
+
+	switch {
+	case yamlInt.MatchString(s), yamlFloat.MatchString(s):
+		return json.Number(strings.TrimPrefix(s, "+")), true
+	case strings.HasPrefix(s, "0x"):
+		if n, err := strconv.ParseUint(s[2:], 16, 64); err == nil {
+			return json.Number(strconv.FormatUint(n, 10)), true
+		}
+	case strings.HasPrefix(s, "0o"):
+		if n, err := strconv.ParseUint(s[2:], 8, 64); err == nil {
+			return json.Number(strconv.FormatUint(n, 10)), true
+		}
+	}
+	return "", false
+}
+
+// applyYAMLTag applies a standard tag, eg !!str, to a scalar. value is the resolved scalar and text is its text.
+// Other tags are ignored.
+func applyYAMLTag(value interface{}, text string, tag string) interface{} {
+	switch tag {
+	case "!!str":
+		return text
+	case "!!int", "!!float":
+		if n, ok := yamlNumber(text); ok {
+			return n
+		}
+		return text
+	case "!!bool":
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b
+		}
+	case "!!null":
+		return nil
+	}
+	return value
+}
+
+// yamlFlow parses a flow collection.
+type yamlFlow struct {
+	p    *yamlParser
+	s    string
+	i    int
+	line int
+}
+
+func (f *yamlFlow) skipSpace() {
+	for f.i < len(f.s) && (f.s[f.i] == ' ' || f.s[f.i] == '\t' || f.s[f.i] == '\n') {
+		f.i++
+	}
+}
+
+func (f *yamlFlow) value() (interface{}, error) {
+	start := f.p.nodes
+	var anchor, tag string
+	for {
+		f.skipSpace()
+		if f.i < len(f.s) && (f.s[f.i] == '&' || f.s[f.i] == '!') {
+			end := f.i
+			for end < len(f.s) && strings.IndexByte(" \t\n,[]{}", f.s[end]) < 0 {
+				end++
+			}
+			if f.s[f.i] == '&' {
+				anchor = f.s[f.i+1 : end]
+			} else {
+				tag = f.s[f.i:end]
+			}
+			f.i = end
+			continue
+		}
+		break
+	}
+	if f.i >= len(f.s) {
+		return nil, yamlErrorf(f.line, "unterminated flow collection")
+	}
+
+	var value interface{}
+	var err error
+	switch c := f.s[f.i]; c {
+	case '[':
+		value, err = f.sequence()
+	case '{':
+		value, err = f.mapping()
+	case '"', '\'':
+		s, n, ok, qerr := scanYAMLQuoted(f.s[f.i:], f.line)
+		if qerr != nil {
+			return nil, qerr
+		}
+		if !ok {
+			return nil, yamlErrorf(f.line, "unterminated quoted scalar")
+		}
+		f.i += n
+		value = applyYAMLTag(s, s, tag)
+	case '*':
+		f.i++
+		name := f.plain()
+		a, ok := f.p.anchors[name]
+		if !ok {
+			return nil, yamlErrorf(f.line, "unknown alias %q", name)
+		}
+		if err := f.p.count(a.nodes, f.line); err != nil {
+			return nil, err
+		}
+		value = a.value
+	case ']', '}', ',':
+		value = applyYAMLTag(nil, "", tag)
+	default:
+		s := f.plain()
+		value = applyYAMLTag(resolveYAMLScalar(s), s, tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.p.count(1, f.line); err != nil {
+		return nil, err
+	}
+	if anchor != "" {
+		f.p.anchors[anchor] = yamlAnchor{value: value, nodes: f.p.nodes - start}
+	}
+	return value, nil
+}
+
+// plain reads a plain scalar, up to a flow indicator or a ": ".
+func (f *yamlFlow) plain() string {
+	start := f.i
+	for f.i < len(f.s) {
+		c := f.s[f.i]
+		if c == ',' || c == '[' || c == ']' || c == '{' || c == '}' {
+			break
+		}
+		if c == ':' && (f.i+1 == len(f.s) || strings.IndexByte(" \t\n,[]{}", f.s[f.i+1]) >= 0) {
+			break
+		}
+		f.i++
+	}
+	return strings.Join(strings.Fields(f.s[start:f.i]), " ")
+}
+
+func (f *yamlFlow) sequence() (interface{}, error) {
+	f.i++ // [
+	s := []interface{}{}
+	for {
+		f.skipSpace()
+		if f.i >= len(f.s) {
+			return nil, yamlErrorf(f.line, "unterminated flow sequence")
+		}
+		if f.s[f.i] == ']' {
+			f.i++
+			return s, nil
+		}
+		value, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, value)
+		if err := f.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *yamlFlow) mapping() (interface{}, error) {
+	f.i++ // {
+	m := make(map[string]interface{})
+	for {
+		f.skipSpace()
+		if f.i >= len(f.s) {
+			return nil, yamlErrorf(f.line, "unterminated flow mapping")
+		}
+		if f.s[f.i] == '}' {
+			f.i++
+			return m, nil
+		}
+
+		var key string
+		if c := f.s[f.i]; c == '"' || c == '\'' {
+			s, n, ok, err := scanYAMLQuoted(f.s[f.i:], f.line)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, yamlErrorf(f.line, "unterminated quoted scalar")
+			}
+			key = s
+			f.i += n
+		} else {
+			key = f.plain()
+		}
+		if _, ok := m[key]; ok {
+			return nil, yamlErrorf(f.line, "duplicate key %q", key)
+		}
+
+		f.skipSpace()
+		var value interface{}
+		if f.i < len(f.s) && f.s[f.i] == ':' {
+			f.i++
+			var err error
+			if value, err = f.value(); err != nil {
+				return nil, err
+			}
+		}
+		m[key] = value
+		if err := f.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// separator reads the comma after an entry, unless the collection ends with end.
+func (f *yamlFlow) separator(end byte) error {
+	f.skipSpace()
+	if f.i < len(f.s) && f.s[f.i] == ',' {
+		f.i++
+		return nil
+	}
+	if f.i < len(f.s) && f.s[f.i] == end {
+		return nil
+	}
+	return yamlErrorf(f.line, "expected , or %c in a flow collection", end)
+}
@@ -0,0 +1,164 @@
+package meta
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		yaml string
+		want interface{}
+	}{
+		{"a: 1\nb: x\n", map[string]interface{}{"a": json.Number("1"), "b": "x"}},
+		{"# comment\na: 1 # trailing\n", map[string]interface{}{"a": json.Number("1")}},
+		{"a:\n  b:\n    c: true\n", map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": true}}}},
+		{"- 1\n- -2.5\n- ~\n- null\n- yes\n", []interface{}{json.Number("1"), json.Number("-2.5"), nil, nil, "yes"}},
+		{"a:\n- x\n- y\nb: z\n", map[string]interface{}{"a": []interface{}{"x", "y"}, "b": "z"}},
+		{"- a: 1\n  b: 2\n- a: 3\n", []interface{}{
+			map[string]interface{}{"a": json.Number("1"), "b": json.Number("2")},
+			map[string]interface{}{"a": json.Number("3")},
+		}},
+		{"- - a\n  - b\n- - c\n", []interface{}{[]interface{}{"a", "b"}, []interface{}{"c"}}},
+		{"a: [1, 'two', {b: c}]\n", map[string]interface{}{"a": []interface{}{json.Number("1"), "two", map[string]interface{}{"b": "c"}}}},
+		{"a: {b: 1,\n  c: [x, y]}\n", map[string]interface{}{"a": map[string]interface{}{"b": json.Number("1"), "c": []interface{}{"x", "y"}}}},
+		{"a: []\nb: {}\n", map[string]interface{}{"a": []interface{}{}, "b": map[string]interface{}{}}},
+		{`a: "x\ty\u00e9 \"q\""` + "\nb: 'it''s'\n", map[string]interface{}{"a": "x\tyé \"q\"", "b": "it's"}},
+		{"a: \"one\n  two\n\n  three\"\n", map[string]interface{}{"a": "one two\nthree"}},
+		{"a: '10'\nb: \"true\"\nc: !!str 10\n", map[string]interface{}{"a": "10", "b": "true", "c": "10"}},
+		{"a: plain\n  continued\n", map[string]interface{}{"a": "plain continued"}},
+		{"url: http://x.com/a#b\n", map[string]interface{}{"url": "http://x.com/a#b"}},
+		{"a: |\n  line 1\n    indented\n  line 3\n\nb: 1\n", map[string]interface{}{"a": "line 1\n  indented\nline 3\n", "b": json.Number("1")}},
+		{"a: >-\n  folded\n  text\n\n  para\n", map[string]interface{}{"a": "folded text\npara"}},
+		{"a: |+\n  keep\n\n", map[string]interface{}{"a": "keep\n\n"}},
+		{"base: &b\n  x: 1\n  y: 2\nc:\n  <<: *b\n  y: 3\nd: *b\n", map[string]interface{}{
+			"base": map[string]interface{}{"x": json.Number("1"), "y": json.Number("2")},
+			"c":    map[string]interface{}{"x": json.Number("1"), "y": json.Number("3")},
+			"d":    map[string]interface{}{"x": json.Number("1"), "y": json.Number("2")},
+		}},
+		{"- &v 5\n- *v\n", []interface{}{json.Number("5"), json.Number("5")}},
+		{"n: 0x1F\no: 0o17\nf: 1e3\ni: .inf\np: +12\nz: 012\n", map[string]interface{}{"n": "0x1F", "o": "0o17", "f": json.Number("1e3"), "i": ".inf", "p": "+12", "z": "012"}},
+		{"n: !!int 0x1F\no: !!int 0o17\ni: !!float .inf\np: !!int +12\n", map[string]interface{}{"n": json.Number("31"), "o": json.Number("15"), "i": json.Number("+Inf"), "p": json.Number("12")}},
+		{"\"quoted key\": 1\n", map[string]interface{}{"quoted key": json.Number("1")}},
+		{"%YAML 1.2\n---\na: 1\n...\n", map[string]interface{}{"a": json.Number("1")}},
+		{"just a string\n", "just a string"},
+		{"a:\nb: 1\n", map[string]interface{}{"a": nil, "b": json.Number("1")}},
+	}
+
+	for _, test := range tests {
+		docs, err := parseYAML([]byte(test.yaml))
+		if err != nil {
+			t.Errorf("%q: %s", test.yaml, err)
+			continue
+		}
+		if len(docs) != 1 {
+			t.Errorf("%q: %d documents", test.yaml, len(docs))
+			continue
+		}
+		assertEqual(t, docs[0], test.want)
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	for _, yaml := range []string{
+		"a: 1\na: 2\n",
+		"a: 1\n  b: 2\n",
+		"a: [1, 2\n",
+		"a: 'open\n",
+		"a: *missing\n",
+		"a:\n\t b: 1\n",
+		"? complex\n: key\n",
+		"a: 1\n- b\n",
+		"a: \"\\q\"\n",
+		"a: {b: 1]\n",
+	} {
+		if _, err := parseYAML([]byte(yaml)); err == nil {
+			t.Errorf("%q: expected an error", yaml)
+		}
+	}
+
+	_, err := parseYAML([]byte("a: 1\nb: *x\n"))
+	assertEqual(t, err.Error(), `meta: yaml: line 2: unknown alias "x"`)
+}
+
+func TestParseYAMLAliasBomb(t *testing.T) {
+	yaml := "a: &a [x, x, x, x, x, x, x, x, x, x]\n"
+	for i, prev := 'b', 'a'; i <= 'k'; i, prev = i+1, i {
+		yaml += string(i) + ": &" + string(i) + " [" + strings.Repeat("*"+string(prev)+", ", 9) + "*" + string(prev) + "]\n"
+	}
+	_, err := parseYAML([]byte(yaml))
+	assert(t, err != nil)
+}
+
+func TestYAMLStream(t *testing.T) {
+	srcs, err := NewYAMLStream([]byte("a: 1\n---\na: 2\n---\n---\n"))
+	assertEqual(t, err, nil)
+	assertEqual(t, len(srcs), 3)
+
+	var inputs withRequest
+	for i, want := range []string{"1", "2"} {
+		e := withRequestDecoder.DecodeSource(&inputs, srcs[i])
+		assertEqual(t, e, ErrorHash(nil))
+		assertEqual(t, inputs.A.Val, want)
+	}
+	assertEqual(t, srcs[2].Get("a").Empty(), true)
+
+	e := withRequestDecoder.DecodeYAML(&inputs, []byte("a: 1\n---\na: 2\n"))
+	assertEqual(t, e, ErrorHash{"error": ErrMalformed})
+
+	// A trailing --- starts no document.
+	srcs, err = NewYAMLStream([]byte("a: 1\n--- \n"))
+	assertEqual(t, err, nil)
+	assertEqual(t, len(srcs), 1)
+
+	e = withRequestDecoder.DecodeYAML(&inputs, []byte("a: 1\n---\n"))
+	assertEqual(t, e, ErrorHash(nil))
+	assertEqual(t, inputs.A.Val, "1")
+
+	e = withRequestDecoder.DecodeYAML(&inputs, []byte("---\n"))
+	assertEqual(t, e, ErrorHash(nil))
+}
+
+type withYAML struct {
+	Name  String `meta_required:"true"`
+	Count Int64  `meta_min:"1"`
+	Tags  []String
+	Items []struct {
+		Price Float64 `meta_required:"true"`
+	}
+	When Time
+}
+
+func TestDecodeYAMLMatchesJSON(t *testing.T) {
+	d := NewDecoder(&withYAML{})
+	tests := []struct {
+		yaml, json string
+	}{
+		{"name: x\ncount: 2\ntags: [a, b]\nitems:\n- price: 1.5\nwhen: 2020-01-02T03:04:05Z\n",
+			`{"name":"x","count":2,"tags":["a","b"],"items":[{"price":1.5}],"when":"2020-01-02T03:04:05Z"}`},
+		{"count: 0\ntags:\n- {}\nitems:\n- price: 1\n- {}\n", `{"count":0,"tags":[{}],"items":[{"price":1},{}]}`},
+		{"name: ~\ncount: abc\n", `{"name":null,"count":"abc"}`},
+		{"name: [x\n", `{"name":["x"`},
+		{"- 1\n", `[1]`},
+		{"name: 0x1F\ncount: +12\ntags: [0o17, .nan, 012]\n", `{"name":"0x1F","count":"+12","tags":["0o17",".nan","012"]}`},
+		{"name: 1.50\ntags: [-0, 1e3]\n", `{"name":1.50,"tags":[-0,1e3]}`},
+	}
+	for _, test := range tests {
+		var fromYAML, fromJSON withYAML
+		assertEqual(t, d.DecodeYAML(&fromYAML, []byte(test.yaml)), d.DecodeJSON(&fromJSON, []byte(test.json)))
+		assertEqual(t, fromYAML, fromJSON)
+	}
+}
+
+func TestDecodeRequestYAML(t *testing.T) {
+	assertDecodesRequest(t, "application/yaml", "a: '1'\nc:\n  d: 3\n")
+
+	var inputs withRequest
+	d := NewDecoderWithOptions(&withRequest{}, DecoderOptions{MaxDepth: 1})
+	req := httptest.NewRequest("POST", "/", strings.NewReader("c:\n  d: 3\n"))
+	req.Header.Set("Content-Type", "text/yaml")
+	e := d.DecodeRequest(&inputs, req)
+	assertEqual(t, e, ErrorHash{"error": ErrMaxDepth})
+}